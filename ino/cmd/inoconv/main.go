@@ -0,0 +1,74 @@
+// inoconv converts a field between the level file format and the Tiled formats.
+//
+// Usage:
+//
+//	inoconv [-image path] -o out.tmx [file]
+//
+// The format is determined by the extension: .inofield, .tmx or .tmj.
+// If no file is given, inoconv converts the built-in field.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+)
+
+var (
+	output = flag.String("o", "", "output file (.inofield, .tmx or .tmj)")
+	image  = flag.String("image", "ino.png", "path of the tile image relative to the Tiled map")
+)
+
+func loadField() (*field.Field, error) {
+	if flag.NArg() == 0 {
+		return field.Load(assets.Assets, "fields/inovation.inofield")
+	}
+	return field.LoadFile(flag.Arg(0))
+}
+
+func write(w io.Writer, f *field.Field) error {
+	switch ext := strings.ToLower(filepath.Ext(*output)); ext {
+	case ".inofield":
+		return field.Write(w, f)
+	case ".tmx":
+		return field.WriteTMX(w, f, *image)
+	case ".tmj", ".json":
+		return field.WriteTMJ(w, f, *image)
+	default:
+		return fmt.Errorf("inoconv: unknown format %q", ext)
+	}
+}
+
+func run() error {
+	if *output == "" {
+		return fmt.Errorf("inoconv: -o is required")
+	}
+	f, err := loadField()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(out, f); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
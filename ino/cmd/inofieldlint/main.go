@@ -0,0 +1,200 @@
+// inofieldlint checks level files for structural problems.
+//
+// Usage:
+//
+//	inofieldlint [-json] [file ...]
+//
+// If no files are given, inofieldlint checks the built-in field.
+// inofieldlint exits with 1 if any problems are found.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+var jsonOutput = flag.Bool("json", false, "print the reports in JSON")
+
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Problem struct {
+	Check   string  `json:"check"`
+	Message string  `json:"message"`
+	Line    int     `json:"line,omitempty"`
+	Column  int     `json:"column,omitempty"`
+	Tiles   []Point `json:"tiles,omitempty"`
+}
+
+type Report struct {
+	File     string         `json:"file"`
+	Name     string         `json:"name,omitempty"`
+	Width    int            `json:"width,omitempty"`
+	Height   int            `json:"height,omitempty"`
+	Problems []Problem      `json:"problems"`
+	Items    map[string]int `json:"items,omitempty"`
+}
+
+func lint(name string, load func(name string) (*field.Field, error), newWorld func(name string, f *field.Field) (*field.World, error)) (*Report, error) {
+	r := &Report{
+		File:     name,
+		Problems: []Problem{},
+	}
+
+	f, err := load(name)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return nil, err
+		}
+		var errs field.ErrorList
+		if !errors.As(err, &errs) {
+			// Errors in Tiled maps don't have positions.
+			r.Problems = append(r.Problems, Problem{
+				Check:   "syntax",
+				Message: strings.TrimPrefix(err.Error(), name+": "),
+			})
+			return r, nil
+		}
+		for _, e := range errs {
+			r.Problems = append(r.Problems, Problem{
+				Check:   "syntax",
+				Message: e.Msg,
+				Line:    e.Line,
+				Column:  e.Column,
+			})
+		}
+		return r, nil
+	}
+
+	r.Name = f.Name()
+	r.Width = f.Width()
+	r.Height = f.Height()
+
+	switch ps := f.Find(fieldtype.FIELD_ITEM_STARTPOINT); len(ps) {
+	case 0:
+		r.Problems = append(r.Problems, Problem{
+			Check:   "startpoint",
+			Message: "no start point",
+		})
+	case 1:
+	default:
+		p := Problem{
+			Check:   "startpoint",
+			Message: fmt.Sprintf("%d start points", len(ps)),
+		}
+		for _, pt := range ps {
+			p.Tiles = append(p.Tiles, Point{pt.X, pt.Y})
+		}
+		r.Problems = append(r.Problems, p)
+	}
+
+	for _, t := range fieldtype.ClearFlagItems {
+		if len(f.Find(t)) == 0 {
+			r.Problems = append(r.Problems, Problem{
+				Check:   "clearitem",
+				Message: fmt.Sprintf("no %s required to clear the game", t),
+			})
+		}
+	}
+
+	for _, p := range f.Find(fieldtype.FIELD_WARP) {
+		if _, ok := f.Warp(p.X, p.Y); !ok {
+			r.Problems = append(r.Problems, Problem{
+				Check:   "warp",
+				Message: fmt.Sprintf("warp at (%d, %d) has no destination", p.X, p.Y),
+				Tiles:   []Point{{p.X, p.Y}},
+			})
+		}
+	}
+	if _, err := newWorld(name, f); err != nil {
+		r.Problems = append(r.Problems, Problem{
+			Check:   "warp",
+			Message: err.Error(),
+		})
+	}
+
+	r.Items = map[string]int{}
+	for t := fieldtype.FIELD_ITEM_POWERUP; t < fieldtype.FIELD_ITEM_MAX; t++ {
+		r.Items[t.String()] = len(f.Find(t))
+	}
+	return r, nil
+}
+
+func printReport(r *Report) {
+	for _, p := range r.Problems {
+		switch {
+		case p.Column != 0:
+			fmt.Printf("%s:%d:%d: %s\n", r.File, p.Line, p.Column, p.Message)
+		case p.Line != 0:
+			fmt.Printf("%s:%d: %s\n", r.File, p.Line, p.Message)
+		case len(p.Tiles) > 0:
+			fmt.Printf("%s: %s at %v\n", r.File, p.Message, p.Tiles)
+		default:
+			fmt.Printf("%s: %s\n", r.File, p.Message)
+		}
+	}
+	if r.Items == nil {
+		return
+	}
+	fmt.Printf("%s: %q (%dx%d)\n", r.File, r.Name, r.Width, r.Height)
+	for t := fieldtype.FIELD_ITEM_POWERUP; t < fieldtype.FIELD_ITEM_MAX; t++ {
+		fmt.Printf("\t%-16s %d\n", t, r.Items[t.String()])
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	var reports []*Report
+	if flag.NArg() == 0 {
+		r, err := lint("fields/inovation.inofield", func(name string) (*field.Field, error) {
+			return field.Load(assets.Assets, name)
+		}, func(name string, f *field.Field) (*field.World, error) {
+			return field.LoadWorld(assets.Assets, name)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		reports = append(reports, r)
+	}
+	for _, name := range flag.Args() {
+		r, err := lint(name, field.LoadFile, field.NewWorldFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		reports = append(reports, r)
+	}
+
+	if *jsonOutput {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "\t")
+		if err := e.Encode(reports); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	} else {
+		for _, r := range reports {
+			printReport(r)
+		}
+	}
+
+	for _, r := range reports {
+		if len(r.Problems) > 0 {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// inomap renders a whole field into a PNG file.
+//
+// Usage:
+//
+//	inomap [-o map.png] [-mono] [-bg] [file]
+//
+// If no file is given, inomap renders the built-in field.
+// inomap doesn't require a GPU or a window.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+)
+
+var (
+	output     = flag.String("o", "map.png", "output PNG file")
+	mono       = flag.Bool("mono", false, "use the monochrome images")
+	background = flag.Bool("bg", false, "draw the background image behind the field")
+)
+
+// gameData shows all the items including the hidden secret.
+type gameData struct{}
+
+func (gameData) IsHiddenSecret() bool {
+	return false
+}
+
+func loadField() (*field.Field, error) {
+	if flag.NArg() == 0 {
+		return field.Load(assets.Assets, "fields/inovation.inofield")
+	}
+	return field.LoadFile(flag.Arg(0))
+}
+
+func loadImage(name string) (image.Image, error) {
+	dir := "images/color"
+	if *mono {
+		dir = "images/mono"
+	}
+	f, err := assets.Assets.Open(dir + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func render(f *field.Field) (image.Image, error) {
+	tiles, err := loadImage("ino.png")
+	if err != nil {
+		return nil, err
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, f.Width()*field.CHAR_SIZE, f.Height()*field.CHAR_SIZE))
+	if *background {
+		// The game draws the top-left part of the background image on the screen.
+		const (
+			bgWidth  = 320
+			bgHeight = 240
+		)
+		bg, err := loadImage("bg.png")
+		if err != nil {
+			return nil, err
+		}
+		for y := 0; y < dst.Bounds().Dy(); y += bgHeight {
+			for x := 0; x < dst.Bounds().Dx(); x += bgWidth {
+				draw.Draw(dst, image.Rect(x, y, x+bgWidth, y+bgHeight), bg, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	for y := 0; y < f.Height(); y++ {
+		for x := 0; x < f.Width(); x++ {
+			sx, sy, ok := f.ImagePosition(x, y, gameData{})
+			if !ok {
+				continue
+			}
+			r := image.Rect(x*field.CHAR_SIZE, y*field.CHAR_SIZE, (x+1)*field.CHAR_SIZE, (y+1)*field.CHAR_SIZE)
+			draw.Draw(dst, r, tiles, image.Pt(sx, sy), draw.Over)
+		}
+	}
+
+	// Moving platforms are drawn at their first points.
+	for _, p := range f.Platforms() {
+		sx, sy := field.TypeImagePosition(p.Type, 0)
+		for i := 0; i < p.Width; i++ {
+			x, y := p.Path[0].X+i, p.Path[0].Y
+			r := image.Rect(x*field.CHAR_SIZE, y*field.CHAR_SIZE, (x+1)*field.CHAR_SIZE, (y+1)*field.CHAR_SIZE)
+			draw.Draw(dst, r, tiles, image.Pt(sx, sy), draw.Over)
+		}
+	}
+	return dst, nil
+}
+
+func run() error {
+	f, err := loadField()
+	if err != nil {
+		return err
+	}
+	img, err := render(f)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(out, img); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,106 @@
+// inosolve reports which items in a field can be collected.
+//
+// Usage:
+//
+//	inosolve [-lunker] [-requires] [-json] [-max n] [file]
+//
+// If no file is given, inosolve checks the built-in field.
+// inosolve exits with 1 if any items are unreachable.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/solver"
+)
+
+var (
+	lunker     = flag.Bool("lunker", false, "use the rules of lunker mode")
+	requires   = flag.Bool("requires", false, "find the power-ups required for each item (slow)")
+	jsonOutput = flag.Bool("json", false, "print the result in JSON")
+	maxStates  = flag.Int("max", 0, "maximum number of states to search (0 means no limit)")
+)
+
+func loadField() (*field.Field, error) {
+	if flag.NArg() == 0 {
+		return field.Load(assets.Assets, "fields/inovation.inofield")
+	}
+	return field.LoadFile(flag.Arg(0))
+}
+
+func printPoints(label string, ps []image.Point) {
+	if len(ps) == 0 {
+		return
+	}
+	fmt.Print(label)
+	for _, p := range ps {
+		fmt.Printf(" (%d, %d)", p.X, p.Y)
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	f, err := loadField()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// These must be consistent with NewGameData.
+	opts := &solver.Options{
+		JumpMax:   0,
+		LifeMax:   3,
+		Requires:  *requires,
+		MaxStates: *maxStates,
+	}
+	if *lunker {
+		opts.JumpMax = 1
+		opts.LifeMax = 1
+		opts.Lunker = true
+	}
+
+	r, err := solver.Solve(f, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *jsonOutput {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "\t")
+		if err := e.Encode(r); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	} else {
+		for _, it := range r.Items {
+			if !it.Reachable {
+				fmt.Printf("%-16s (%d, %d): unreachable\n", it.Type, it.Position.X, it.Position.Y)
+				continue
+			}
+			fmt.Printf("%-16s (%d, %d): frame %d", it.Type, it.Position.X, it.Position.Y, it.Frame)
+			printPoints(", after", it.Collected)
+			printPoints(", requires", it.Requires)
+			fmt.Println()
+		}
+		printPoints("order:", r.Order)
+		fmt.Println()
+		fmt.Printf("%d states searched\n", r.States)
+		if !r.Complete {
+			fmt.Println("the search was stopped before completion")
+		}
+	}
+
+	for _, it := range r.Items {
+		if !it.Reachable {
+			os.Exit(1)
+		}
+	}
+}
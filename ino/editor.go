@@ -0,0 +1,359 @@
+package ino
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/audio"
+	"github.com/hajimehoshi/go-inovation/ino/internal/draw"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+	"github.com/hajimehoshi/go-inovation/ino/internal/font"
+)
+
+const (
+	EDITOR_NEW_WIDTH      = 112
+	EDITOR_NEW_HEIGHT     = 54
+	EDITOR_PALETTE_COLUMN = draw.ScreenWidth / field.CHAR_SIZE
+	EDITOR_MESSAGE_TIME   = 180
+	EDITOR_KEY_REPEAT     = 20
+)
+
+// editorGameData shows all the tiles in the editor.
+type editorGameData struct{}
+
+func (editorGameData) IsHiddenSecret() bool {
+	return false
+}
+
+// EditorScene is a level editor.
+//
+// Arrow keys or the mouse move the cursor. Space or the left button puts the current tile,
+// and Backspace or the right button erases the tile. Z and X or the mouse wheel change the
+// current tile, and C picks the tile under the cursor. S saves the field, L loads the field,
+// and T starts playing from the cursor. Esc returns to the editor from playing.
+type EditorScene struct {
+	path     string
+	field    *field.Field
+	types    []fieldtype.FieldType
+	current  int
+	cursorX  int
+	cursorY  int
+	viewX    int
+	viewY    int
+	mouseX   int
+	mouseY   int
+	message  string
+	msgTimer int
+	playtest *GameScene
+}
+
+// NewEditorScene returns a level editor for the level file at path.
+// If the file doesn't exist, the editor starts with an empty field.
+func NewEditorScene(path string) (*EditorScene, error) {
+	e := &EditorScene{
+		path:  path,
+		types: fieldtype.All(),
+	}
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+	for i, t := range e.types {
+		if t == fieldtype.FIELD_BLOCK {
+			e.current = i
+		}
+	}
+	if ps := e.field.Find(fieldtype.FIELD_ITEM_STARTPOINT); len(ps) > 0 {
+		e.cursorX, e.cursorY = ps[0].X, ps[0].Y
+	}
+	e.viewX = e.cursorX*field.CHAR_SIZE + field.CHAR_SIZE/2
+	e.viewY = e.cursorY*field.CHAR_SIZE + field.CHAR_SIZE/2
+	return e, nil
+}
+
+func (e *EditorScene) load() error {
+	f, err := field.LoadFile(e.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		e.field = field.New(EDITOR_NEW_WIDTH, EDITOR_NEW_HEIGHT)
+		e.setMessage(fmt.Sprintf("new field %s", e.path))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	e.field = f
+	e.setMessage(fmt.Sprintf("loaded %s", e.path))
+	return nil
+}
+
+func (e *EditorScene) setMessage(msg string) {
+	e.message = msg
+	e.msgTimer = EDITOR_MESSAGE_TIME
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func isKeyRepeated(key ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(key)
+	return d == 1 || (d >= EDITOR_KEY_REPEAT && d%4 == 0)
+}
+
+// screenToField returns the tile position at the screen position (x, y).
+func (e *EditorScene) screenToField(x, y int) (int, int) {
+	fx := x + e.viewX - draw.ScreenWidth/2
+	fy := y + e.viewY - draw.ScreenHeight/2
+	if fx < 0 {
+		fx -= field.CHAR_SIZE - 1
+	}
+	if fy < 0 {
+		fy -= field.CHAR_SIZE - 1
+	}
+	return fx / field.CHAR_SIZE, fy / field.CHAR_SIZE
+}
+
+// fieldToScreen returns the screen position of the tile at (x, y).
+func (e *EditorScene) fieldToScreen(x, y int) (int, int) {
+	return x*field.CHAR_SIZE - e.viewX + draw.ScreenWidth/2, y*field.CHAR_SIZE - e.viewY + draw.ScreenHeight/2
+}
+
+// paletteHeight returns the height of the palette, which has as many rows as needed for all the tiles.
+func (e *EditorScene) paletteHeight() int {
+	return (len(e.types) + EDITOR_PALETTE_COLUMN - 1) / EDITOR_PALETTE_COLUMN * field.CHAR_SIZE
+}
+
+func (e *EditorScene) paletteRect(i int) (x, y int) {
+	return (i % EDITOR_PALETTE_COLUMN) * field.CHAR_SIZE, draw.ScreenHeight - e.paletteHeight() + (i/EDITOR_PALETTE_COLUMN)*field.CHAR_SIZE
+}
+
+func (e *EditorScene) selectTile(delta int) {
+	e.current = (e.current + delta + len(e.types)) % len(e.types)
+}
+
+func (e *EditorScene) startPlaytest(game *Game) {
+	f := e.field.Clone()
+	for _, p := range f.Find(fieldtype.FIELD_ITEM_STARTPOINT) {
+		f.EraseField(p.X, p.Y)
+	}
+	f.SetField(e.cursorX, e.cursorY, fieldtype.FIELD_ITEM_STARTPOINT)
+
+	// The other fields linked by the warps are read from their files.
+	w, err := field.NewWorldFile(e.path, f)
+	if err != nil {
+		e.setMessage(err.Error())
+		return
+	}
+
+	game.gameData = NewGameData(GAMEMODE_NORMAL)
+	e.playtest = newGameScene(game.gameData, w, time.Now().UnixNano())
+}
+
+func (e *EditorScene) updatePlaytest(game *Game) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || e.playtest.Msg() != GAMESTATE_MSG_NONE {
+		// Return to the editor at the player's position if the player is in the edited field.
+		if e.playtest.player.fieldName == e.path {
+			e.cursorX = e.playtest.player.toFieldX()
+			e.cursorY = e.playtest.player.toFieldY()
+		}
+		e.viewX = e.cursorX*field.CHAR_SIZE + field.CHAR_SIZE/2
+		e.viewY = e.cursorY*field.CHAR_SIZE + field.CHAR_SIZE/2
+		e.playtest = nil
+		audio.PauseBGM()
+		return
+	}
+	e.playtest.Update(game)
+}
+
+func (e *EditorScene) Update(game *Game) {
+	if e.playtest != nil {
+		e.updatePlaytest(game)
+		return
+	}
+
+	e.field.Update()
+	if e.msgTimer > 0 {
+		e.msgTimer--
+	}
+
+	// Move the cursor by the keys.
+	moved := false
+	if isKeyRepeated(ebiten.KeyLeft) {
+		e.cursorX--
+		moved = true
+	}
+	if isKeyRepeated(ebiten.KeyRight) {
+		e.cursorX++
+		moved = true
+	}
+	if isKeyRepeated(ebiten.KeyUp) {
+		e.cursorY--
+		moved = true
+	}
+	if isKeyRepeated(ebiten.KeyDown) {
+		e.cursorY++
+		moved = true
+	}
+
+	// Move the cursor by the mouse.
+	mx, my := ebiten.CursorPosition()
+	inPalette := my >= draw.ScreenHeight-e.paletteHeight()
+	if (mx != e.mouseX || my != e.mouseY) && !inPalette {
+		e.cursorX, e.cursorY = e.screenToField(mx, my)
+	}
+	e.mouseX, e.mouseY = mx, my
+
+	e.cursorX = clamp(e.cursorX, 0, e.field.Width()-1)
+	e.cursorY = clamp(e.cursorY, 0, e.field.Height()-1)
+
+	// Scroll the view so that the cursor is visible.
+	if moved {
+		const margin = 3 * field.CHAR_SIZE
+		sx, sy := e.fieldToScreen(e.cursorX, e.cursorY)
+		if sx < margin {
+			e.viewX -= margin - sx
+		}
+		if sx > draw.ScreenWidth-margin-field.CHAR_SIZE {
+			e.viewX += sx - (draw.ScreenWidth - margin - field.CHAR_SIZE)
+		}
+		if sy < margin {
+			e.viewY -= margin - sy
+		}
+		if sy > draw.ScreenHeight-e.paletteHeight()-margin-field.CHAR_SIZE {
+			e.viewY += sy - (draw.ScreenHeight - e.paletteHeight() - margin - field.CHAR_SIZE)
+		}
+	}
+
+	// Select a tile.
+	if inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		e.selectTile(-1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyX) {
+		e.selectTile(1)
+	}
+	if _, wy := ebiten.Wheel(); wy > 0 {
+		e.selectTile(-1)
+	} else if wy < 0 {
+		e.selectTile(1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonMiddle) {
+		t := e.field.GetField(e.cursorX, e.cursorY)
+		for i, tt := range e.types {
+			if tt == t {
+				e.current = i
+			}
+		}
+	}
+	if inPalette && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		for i := range e.types {
+			x, y := e.paletteRect(i)
+			if x <= mx && mx < x+field.CHAR_SIZE && y <= my && my < y+field.CHAR_SIZE {
+				e.current = i
+			}
+		}
+	}
+
+	// Put or erase a tile.
+	if !inPalette && ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) || ebiten.IsKeyPressed(ebiten.KeySpace) {
+		e.field.SetField(e.cursorX, e.cursorY, e.types[e.current])
+	}
+	if !inPalette && ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) || ebiten.IsKeyPressed(ebiten.KeyBackspace) || ebiten.IsKeyPressed(ebiten.KeyDelete) {
+		e.field.EraseField(e.cursorX, e.cursorY)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		if err := field.SaveFile(e.path, e.field); err != nil {
+			e.setMessage(err.Error())
+		} else {
+			e.setMessage(fmt.Sprintf("saved %s", e.path))
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+		if err := e.load(); err != nil {
+			e.setMessage(err.Error())
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		e.startPlaytest(game)
+	}
+}
+
+func drawFrame(screen *ebiten.Image, x, y, width, height int, clr color.Color) {
+	fx, fy, fw, fh := float64(x), float64(y), float64(width), float64(height)
+	ebitenutil.DrawRect(screen, fx, fy, fw, 1, clr)
+	ebitenutil.DrawRect(screen, fx, fy+fh-1, fw, 1, clr)
+	ebitenutil.DrawRect(screen, fx, fy, 1, fh, clr)
+	ebitenutil.DrawRect(screen, fx+fw-1, fy, 1, fh, clr)
+}
+
+func (e *EditorScene) Draw(screen *ebiten.Image, game *Game) {
+	if e.playtest != nil {
+		e.playtest.Draw(screen, game)
+		return
+	}
+
+	if !game.transparent {
+		draw.DrawSprite(screen, "bg", 0, 0, 0)
+	}
+	draw.DrawField(screen, e.field, editorGameData{}, e.viewX, e.viewY)
+	draw.DrawZones(screen, e.field, editorGameData{}, e.viewX, e.viewY)
+
+	// Draw the outside of the field.
+	outside := color.RGBA{0x80, 0x80, 0x80, 0x80}
+	x0, y0 := e.fieldToScreen(0, 0)
+	x1, y1 := e.fieldToScreen(e.field.Width(), e.field.Height())
+	if x0 > 0 {
+		ebitenutil.DrawRect(screen, 0, 0, float64(x0), draw.ScreenHeight, outside)
+	}
+	if x1 < draw.ScreenWidth {
+		ebitenutil.DrawRect(screen, float64(x1), 0, float64(draw.ScreenWidth-x1), draw.ScreenHeight, outside)
+	}
+	cx0 := clamp(x0, 0, draw.ScreenWidth)
+	cx1 := clamp(x1, 0, draw.ScreenWidth)
+	if y0 > 0 {
+		ebitenutil.DrawRect(screen, float64(cx0), 0, float64(cx1-cx0), float64(y0), outside)
+	}
+	if y1 < draw.ScreenHeight {
+		ebitenutil.DrawRect(screen, float64(cx0), float64(y1), float64(cx1-cx0), float64(draw.ScreenHeight-y1), outside)
+	}
+
+	// Draw the cursor.
+	cx, cy := e.fieldToScreen(e.cursorX, e.cursorY)
+	drawFrame(screen, cx-1, cy-1, field.CHAR_SIZE+2, field.CHAR_SIZE+2, color.RGBA{0xe4, 0x32, 0x60, 0xff})
+
+	// Draw the palette.
+	py := draw.ScreenHeight - e.paletteHeight()
+	ebitenutil.DrawRect(screen, 0, float64(py), draw.ScreenWidth, float64(e.paletteHeight()), color.White)
+	for i, t := range e.types {
+		x, y := e.paletteRect(i)
+		sx, sy := field.TypeImagePosition(t, 0)
+		draw.Draw(screen, "ino", x, y, sx, sy, field.CHAR_SIZE, field.CHAR_SIZE)
+	}
+	px, py := e.paletteRect(e.current)
+	drawFrame(screen, px, py, field.CHAR_SIZE, field.CHAR_SIZE, color.RGBA{0xe4, 0x32, 0x60, 0xff})
+
+	// Draw the status.
+	status := fmt.Sprintf("(%d, %d) %s  pen: %s", e.cursorX, e.cursorY, e.field.GetField(e.cursorX, e.cursorY), e.types[e.current])
+	if e.msgTimer > 0 {
+		status = e.message
+	}
+	ebitenutil.DrawRect(screen, 0, 0, draw.ScreenWidth, font.LineHeight, color.White)
+	font.DrawText(screen, status, 0, 0, color.Black)
+}
+
+func (e *EditorScene) Msg() GameStateMsg {
+	return GAMESTATE_MSG_NONE
+}
@@ -0,0 +1,279 @@
+package ino
+
+import (
+	"encoding/json"
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/draw"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+	"github.com/hajimehoshi/go-inovation/ino/internal/physics"
+)
+
+const (
+	ENEMY_HITBOX_MARGIN   = 3
+	ENEMY_PATROL_SPEED    = 0.5
+	ENEMY_BOUNCE_SPEED    = 0.75
+	ENEMY_BOUNCE_JUMP     = -3.5
+	ENEMY_DROP_WAIT       = 60
+	ENEMY_DROP_RISE_SPEED = 0.5
+)
+
+// Entity is an actor in the field other than the player, like an enemy.
+type Entity interface {
+	// Update is called every frame while the player is in the field f.
+	Update(f *field.Field, player *Player)
+
+	Draw(screen *ebiten.Image, view *View)
+
+	// Hitbox returns the area in the field that damages the player, in pixels.
+	Hitbox() image.Rectangle
+
+	// Clone returns a copy of the entity for a savestate.
+	Clone() Entity
+
+	// Type returns the marker tile that spawns the entity.
+	Type() fieldtype.FieldType
+
+	// The states of an entity are saved in a session as JSON.
+	json.Marshaler
+	json.Unmarshaler
+}
+
+// entitySpawners creates the entities at the marker tiles of their types.
+var entitySpawners = map[fieldtype.FieldType]func(pos PositionF) Entity{
+	fieldtype.FIELD_ENEMY_PATROL: newPatrolEnemy,
+	fieldtype.FIELD_ENEMY_BOUNCE: newBounceEnemy,
+	fieldtype.FIELD_ENEMY_DROP:   newDropEnemy,
+}
+
+// spawnEntities creates the entities at the marker tiles in f, and erases the markers.
+func spawnEntities(f *field.Field) []Entity {
+	var es []Entity
+	for _, t := range fieldtype.All() {
+		spawn, ok := entitySpawners[t]
+		if !ok {
+			continue
+		}
+		for _, p := range f.Find(t) {
+			f.EraseField(p.X, p.Y)
+			es = append(es, spawn(PositionF{X: float64(p.X * field.CHAR_SIZE), Y: float64(p.Y * field.CHAR_SIZE)}))
+		}
+	}
+	return es
+}
+
+// hitbox returns the hitbox of a character at pos.
+func hitbox(pos PositionF) image.Rectangle {
+	x, y := int(pos.X), int(pos.Y)
+	return image.Rect(x+ENEMY_HITBOX_MARGIN, y+ENEMY_HITBOX_MARGIN, x+field.CHAR_SIZE-ENEMY_HITBOX_MARGIN, y+field.CHAR_SIZE)
+}
+
+// enemy is the common part of the enemies.
+// An enemy moves with the same physics as the player.
+type enemy struct {
+	fieldType fieldtype.FieldType
+	body      physics.Body
+	timer     int
+}
+
+func (e *enemy) Draw(screen *ebiten.Image, view *View) {
+	v := view.ToScreenPosition(e.body.Position)
+	sx, sy := field.TypeImagePosition(e.fieldType, e.timer)
+	draw.Draw(screen, "ino", int(v.X), int(v.Y), sx, sy, field.CHAR_SIZE, field.CHAR_SIZE)
+}
+
+func (e *enemy) Hitbox() image.Rectangle {
+	return hitbox(e.body.Position)
+}
+
+func (e *enemy) Type() fieldtype.FieldType {
+	return e.fieldType
+}
+
+type enemyJSON struct {
+	Body  physics.Body `json:"body"`
+	Timer int          `json:"timer"`
+}
+
+func (e *enemy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(enemyJSON{
+		Body:  e.body,
+		Timer: e.timer,
+	})
+}
+
+func (e *enemy) UnmarshalJSON(data []byte) error {
+	var j enemyJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	e.body = j.Body
+	e.timer = j.Timer
+	return nil
+}
+
+// walk moves the enemy horizontally at speed, and turns at walls.
+func (e *enemy) walk(f *field.Field, speed float64) {
+	e.body.Carry(f)
+	e.body.Speed.X = float64(e.body.Direction) * speed
+	e.body.Fall(f)
+	e.body.Collide(f, false)
+	if e.body.Speed.X == 0 {
+		e.body.Direction = -e.body.Direction
+	}
+}
+
+// patrolEnemy walks along the floor and turns at walls and edges.
+type patrolEnemy struct {
+	enemy
+}
+
+func newPatrolEnemy(pos PositionF) Entity {
+	e := &patrolEnemy{}
+	e.fieldType = fieldtype.FIELD_ENEMY_PATROL
+	e.body = physics.NewBody(pos)
+	e.body.Direction = -1
+	return e
+}
+
+func (e *patrolEnemy) Clone() Entity {
+	e2 := *e
+	return &e2
+}
+
+func (e *patrolEnemy) Update(f *field.Field, player *Player) {
+	e.timer++
+	e.walk(f, ENEMY_PATROL_SPEED)
+	if !e.body.OnWall(f) {
+		return
+	}
+	// 床の端で折り返す
+	x := int(e.body.Position.X) - 1
+	if e.body.Direction > 0 {
+		x = int(e.body.Position.X) + field.CHAR_SIZE
+	}
+	if x < 0 || !f.IsRidable(x/field.CHAR_SIZE, e.body.ToFieldY()+1) {
+		e.body.Direction = -e.body.Direction
+	}
+}
+
+// bounceEnemy keeps jumping and turns at walls.
+type bounceEnemy struct {
+	enemy
+}
+
+func newBounceEnemy(pos PositionF) Entity {
+	e := &bounceEnemy{}
+	e.fieldType = fieldtype.FIELD_ENEMY_BOUNCE
+	e.body = physics.NewBody(pos)
+	e.body.Direction = -1
+	return e
+}
+
+func (e *bounceEnemy) Clone() Entity {
+	e2 := *e
+	return &e2
+}
+
+func (e *bounceEnemy) Update(f *field.Field, player *Player) {
+	e.timer++
+	e.walk(f, ENEMY_BOUNCE_SPEED)
+	if e.body.OnWall(f) && e.body.Speed.Y >= 0 {
+		e.body.Speed.Y = ENEMY_BOUNCE_JUMP
+	}
+}
+
+type dropState int
+
+const (
+	DROPSTATE_WAIT dropState = iota
+	DROPSTATE_FALL
+	DROPSTATE_LANDED
+	DROPSTATE_RISE
+)
+
+// dropEnemy stays in the air, falls when the player passes below, and goes back up.
+type dropEnemy struct {
+	enemy
+	home  PositionF
+	state dropState
+	wait  int
+}
+
+func newDropEnemy(pos PositionF) Entity {
+	e := &dropEnemy{
+		home: pos,
+	}
+	e.fieldType = fieldtype.FIELD_ENEMY_DROP
+	e.body = physics.NewBody(pos)
+	return e
+}
+
+type dropEnemyJSON struct {
+	enemyJSON
+	Home  PositionF `json:"home"`
+	State dropState `json:"state"`
+	Wait  int       `json:"wait"`
+}
+
+func (e *dropEnemy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dropEnemyJSON{
+		enemyJSON: enemyJSON{
+			Body:  e.body,
+			Timer: e.timer,
+		},
+		Home:  e.home,
+		State: e.state,
+		Wait:  e.wait,
+	})
+}
+
+func (e *dropEnemy) UnmarshalJSON(data []byte) error {
+	var j dropEnemyJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	e.body = j.Body
+	e.timer = j.Timer
+	e.home = j.Home
+	e.state = j.State
+	e.wait = j.Wait
+	return nil
+}
+
+func (e *dropEnemy) Clone() Entity {
+	e2 := *e
+	return &e2
+}
+
+func (e *dropEnemy) Update(f *field.Field, player *Player) {
+	e.timer++
+	switch e.state {
+	case DROPSTATE_WAIT:
+		p := player.body.Position
+		if math.Abs(p.X-e.body.Position.X) < field.CHAR_SIZE && p.Y > e.body.Position.Y {
+			e.state = DROPSTATE_FALL
+		}
+	case DROPSTATE_FALL:
+		e.body.Fall(f)
+		if landed, _ := e.body.Collide(f, false); landed {
+			e.state = DROPSTATE_LANDED
+			e.wait = 0
+		}
+	case DROPSTATE_LANDED:
+		e.wait++
+		if e.wait > ENEMY_DROP_WAIT {
+			e.state = DROPSTATE_RISE
+		}
+	case DROPSTATE_RISE:
+		e.body.Position.Y -= ENEMY_DROP_RISE_SPEED
+		if e.body.Position.Y <= e.home.Y {
+			e.body = physics.NewBody(e.home)
+			e.state = DROPSTATE_WAIT
+		}
+	}
+}
@@ -4,37 +4,173 @@ import (
 	"flag"
 	"fmt"
 	_ "image/png"
+	"log"
 	"os"
+	"path/filepath"
 	"runtime/pprof"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"golang.org/x/text/language"
 
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
 	"github.com/hajimehoshi/go-inovation/ino/internal/audio"
 	"github.com/hajimehoshi/go-inovation/ino/internal/draw"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
 	"github.com/hajimehoshi/go-inovation/ino/internal/input"
 	"github.com/hajimehoshi/go-inovation/ino/internal/lang"
+	"github.com/hajimehoshi/go-inovation/ino/internal/replay"
+	"github.com/hajimehoshi/go-inovation/ino/internal/text"
 )
 
 type Game struct {
 	resourceLoadedCh chan error
 	scene            Scene
 	gameData         *GameData
+	world            *field.World
 	lang             language.Tag
 	cpup             *os.File
 	transparent      bool
+
+	// recordPath is the file where the plays are recorded, or empty.
+	recordPath string
+
+	// ghost is the ghost file chosen on the title, or GHOST_BEST or GHOST_OFF.
+	ghost string
+
+	// tas is the TAS mode, or nil.
+	tas *TAS
+
+	// sessionDisabled is true when the session can't be saved in this environment.
+	sessionDisabled bool
 }
 
 var (
 	cpuProfile = flag.String("cpuprofile", "", "write cpu profile to file")
 	mute       = flag.Bool("mute", false, "mute")
+	theme      = flag.String("theme", draw.DefaultTheme, "image theme: color, mono, or a directory that has the images")
+	modsDir    = flag.String("mods", "", "directory of the mods (default $XDG_DATA_HOME/inovation/mods)")
 )
 
+// themes returns the themes that can be chosen in the options:
+// the built-in ones and the one specified by -theme.
+func themes() []string {
+	for _, t := range draw.BuiltinThemes {
+		if t == *theme {
+			return draw.BuiltinThemes
+		}
+	}
+	return append(draw.BuiltinThemes[:len(draw.BuiltinThemes):len(draw.BuiltinThemes)], *theme)
+}
+
+// nextTheme returns the theme d steps after the current one.
+func nextTheme(d int) string {
+	ts := themes()
+	return ts[nextIndex(ts, draw.Theme(), d)]
+}
+
+// nextIndex returns the index d steps after current in choices, wrapping around.
+// It returns 0 when current is not in choices.
+func nextIndex(choices []string, current string, d int) int {
+	for i, c := range choices {
+		if c == current {
+			return ((i+d)%len(choices) + len(choices)) % len(choices)
+		}
+	}
+	return 0
+}
+
 func (g *Game) SetTransparent() {
 	g.transparent = true
 }
 
+// LoadField replaces the built-in field with the level file at path.
+// The level files reachable by the warps are loaded together.
+func (g *Game) LoadField(path string) error {
+	w, err := field.LoadWorldFile(path)
+	if err != nil {
+		return err
+	}
+	_, f := w.Start()
+	if n := len(f.Find(fieldtype.FIELD_ITEM_STARTPOINT)); n != 1 {
+		return fmt.Errorf("ino: %s must have exactly one start point but has %d", path, n)
+	}
+	g.world = w
+	return nil
+}
+
+// StartEditor starts the level editor for the level file at path instead of the title.
+func (g *Game) StartEditor(path string) error {
+	e, err := NewEditorScene(path)
+	if err != nil {
+		return err
+	}
+	g.scene = e
+	return nil
+}
+
+// RecordTo records the plays into the replay file at path.
+// The file is written when a play ends, so it has the last play.
+func (g *Game) RecordTo(path string) {
+	g.recordPath = path
+}
+
+// EnableTAS enables the TAS mode, which has pause, frame advance, slow motion and savestates.
+// The plays in the TAS mode don't update the personal bests.
+func (g *Game) EnableTAS() {
+	g.tas = newTAS()
+}
+
+// StartReplay plays the replay file at path back instead of the title.
+// The replay must be recorded on the same field.
+func (g *Game) StartReplay(path string) error {
+	r, err := replay.Load(path)
+	if err != nil {
+		return err
+	}
+	switch GameMode(r.Mode) {
+	case GAMEMODE_NORMAL, GAMEMODE_LUNKER:
+	default:
+		return fmt.Errorf("ino: %s: unknown game mode %d", path, r.Mode)
+	}
+	name, _ := g.world.Start()
+	if r.Field != filepath.Base(name) {
+		return fmt.Errorf("ino: %s is recorded on %s but the field is %s", path, r.Field, filepath.Base(name))
+	}
+	g.gameData = NewGameData(GameMode(r.Mode))
+	g.scene = NewReplayScene(g, r)
+	return nil
+}
+
+// saveRecording writes the play recorded in s, if any.
+func (g *Game) saveRecording(s *GameScene) error {
+	if s.recording == nil {
+		return nil
+	}
+	r := s.recording
+	s.recording = nil
+	return replay.Save(g.recordPath, r)
+}
+
+// Close finishes the game. The play being recorded is written, and the play in progress is saved
+// so that it can be continued from the title.
+func (g *Game) Close() error {
+	s, ok := g.scene.(*GameScene)
+	if !ok {
+		return nil
+	}
+	if s.replay == nil && s.Msg() == GAMESTATE_MSG_NONE {
+		if path, ok := g.sessionPath(); ok {
+			if err := saveSession(path, s); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+	return g.saveRecording(s)
+}
+
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return ScreenWidth, ScreenHeight
 }
@@ -81,6 +217,29 @@ func (g *Game) Update() error {
 		fmt.Println("Stop CPU Profiling")
 	}
 
+	if g.tas != nil && g.scene != nil && !g.tas.Update(g) {
+		return nil
+	}
+
+	if s, ok := g.scene.(*GameScene); ok && s.Msg() != GAMESTATE_MSG_NONE {
+		// 記録を保存できなくてもゲームは続ける
+		if err := g.saveRecording(s); err != nil {
+			log.Print(err)
+		}
+		if path, ok := g.sessionPath(); ok && s.replay == nil {
+			if err := removeSession(path); err != nil {
+				log.Print(err)
+			}
+		}
+		if s.Msg() == GAMESTATE_MSG_REQ_ENDING && s.run != nil && g.tas == nil {
+			s.run.Time = g.gameData.TimeInFrame()
+			// 自己ベストを保存できない環境では保存しない
+			if err := saveBestGhost(s.run, g.world); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+
 	if g.scene == nil {
 		g.scene = &TitleScene{}
 	} else {
@@ -95,6 +254,16 @@ func (g *Game) Update() error {
 			g.scene = &OpeningScene{}
 		case GAMESTATE_MSG_REQ_GAME:
 			g.scene = NewGameScene(g)
+		case GAMESTATE_MSG_REQ_CONTINUE:
+			s, err := loadSession(g)
+			if err != nil {
+				log.Print(err)
+				g.scene = &TitleScene{}
+				break
+			}
+			g.scene = s
+		case GAMESTATE_MSG_REQ_OPTIONS:
+			g.scene = &OptionsScene{}
 		case GAMESTATE_MSG_REQ_ENDING:
 			if err := audio.PlayBGM(audio.BGM1); err != nil {
 				return err
@@ -113,6 +282,15 @@ func (g *Game) Update() error {
 		}
 	}
 	g.scene.Update(g)
+
+	// 落ちても続きから遊べるように定期的に保存する
+	if s, ok := g.scene.(*GameScene); ok && s.replay == nil && s.Msg() == GAMESTATE_MSG_NONE && s.frame%SESSION_SAVE_INTERVAL == 0 {
+		if path, ok := g.sessionPath(); ok {
+			if err := saveSession(path, s); err != nil {
+				log.Print(err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -123,6 +301,42 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 	g.scene.Draw(screen, g)
 	ebitenutil.DebugPrint(screen, fmt.Sprintf("\nFPS: %.2f", ebiten.CurrentFPS()))
+	if g.tas != nil {
+		ebitenutil.DebugPrint(screen, "\n\n"+g.tas.Status(g))
+	}
+}
+
+// loadMods layers the mods over the assets, and reloads the assets read before.
+func loadMods() error {
+	if dir := *modsDir; dir != "" {
+		if err := assets.LoadMods(dir); err != nil {
+			return err
+		}
+	} else {
+		// モッドが使えない環境ではモッドなし
+		d, err := assets.DefaultModsDir()
+		if err != nil {
+			return nil
+		}
+		if err := assets.LoadMods(d); err != nil {
+			log.Print(err)
+			return nil
+		}
+	}
+	if len(assets.Mods()) == 0 {
+		return nil
+	}
+	log.Printf("mods: %s", strings.Join(assets.Mods(), ", "))
+
+	f, err := assets.FS.Open("tiles.json")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := fieldtype.LoadTiles(f, "tiles.json"); err != nil {
+		return err
+	}
+	return text.Load(assets.FS)
 }
 
 func NewGame() (*Game, error) {
@@ -130,12 +344,22 @@ func NewGame() (*Game, error) {
 		audio.Mute()
 	}
 
+	if err := loadMods(); err != nil {
+		return nil, err
+	}
+
+	w, err := field.LoadWorld(assets.FS, "fields/inovation.inofield")
+	if err != nil {
+		return nil, err
+	}
+
 	game := &Game{
 		resourceLoadedCh: make(chan error),
+		world:            w,
 		lang:             lang.SystemLang(),
 	}
 	go func() {
-		if err := draw.LoadImages(); err != nil {
+		if err := draw.SetTheme(*theme); err != nil {
 			game.resourceLoadedCh <- err
 			return
 		}
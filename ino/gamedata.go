@@ -1,6 +1,9 @@
 package ino
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
 )
 
@@ -11,14 +14,8 @@ const (
 	GAMEMODE_LUNKER
 )
 
-var clearFlagItems = [...]fieldtype.FieldType{
-	fieldtype.FIELD_ITEM_FUJI,
-	fieldtype.FIELD_ITEM_TAKA,
-	fieldtype.FIELD_ITEM_NASU,
-}
-
 func IsItemForClear(it fieldtype.FieldType) bool {
-	for _, e := range clearFlagItems {
+	for _, e := range fieldtype.ClearFlagItems {
 		if e == it {
 			return true
 		}
@@ -32,6 +29,8 @@ type GameData struct {
 	jumpMax      int
 	lifeMax      int
 	lunkerMode   bool
+	permadeath   bool // 死んだらタイトルに戻る
+	deaths       int
 }
 
 func NewGameData(gameMode GameMode) *GameData {
@@ -43,11 +42,78 @@ func NewGameData(gameMode GameMode) *GameData {
 	case GAMEMODE_LUNKER:
 		g.lifeMax = 1
 		g.lunkerMode = true
+		g.permadeath = true
 		g.jumpMax = 1
 	}
 	return g
 }
 
+type gameDataJSON struct {
+	// Items are the names of the items got.
+	Items      []string `json:"items"`
+	Time       int      `json:"time"`
+	JumpMax    int      `json:"jumpmax"`
+	LifeMax    int      `json:"lifemax"`
+	Lunker     bool     `json:"lunker"`
+	Permadeath bool     `json:"permadeath"`
+	Deaths     int      `json:"deaths"`
+}
+
+func (g *GameData) MarshalJSON() ([]byte, error) {
+	j := gameDataJSON{
+		Items:      []string{},
+		Time:       g.time,
+		JumpMax:    g.jumpMax,
+		LifeMax:    g.lifeMax,
+		Lunker:     g.lunkerMode,
+		Permadeath: g.permadeath,
+		Deaths:     g.deaths,
+	}
+	for t, got := range g.itemGetFlags {
+		if got {
+			j.Items = append(j.Items, fieldtype.FieldType(t).String())
+		}
+	}
+	return json.Marshal(j)
+}
+
+func (g *GameData) UnmarshalJSON(data []byte) error {
+	var j gameDataJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	g2 := GameData{
+		time:       j.Time,
+		jumpMax:    j.JumpMax,
+		lifeMax:    j.LifeMax,
+		lunkerMode: j.Lunker,
+		permadeath: j.Permadeath,
+		deaths:     j.Deaths,
+	}
+	for _, name := range j.Items {
+		t, ok := fieldtype.Parse(name)
+		if !ok || t >= fieldtype.FIELD_ITEM_MAX {
+			return fmt.Errorf("ino: unknown item %q", name)
+		}
+		g2.itemGetFlags[t] = true
+	}
+	*g = g2
+	return nil
+}
+
+// clone returns a copy of g for a savestate.
+func (g *GameData) clone() *GameData {
+	g2 := *g
+	return &g2
+}
+
+func gameModeOf(g *GameData) GameMode {
+	if g.lunkerMode {
+		return GAMEMODE_LUNKER
+	}
+	return GAMEMODE_NORMAL
+}
+
 func (g *GameData) Update() {
 	g.time++
 }
@@ -56,8 +122,13 @@ func (g *GameData) TimeInFrame() int {
 	return g.time
 }
 
+// Deaths returns how many times the player has died.
+func (g *GameData) Deaths() int {
+	return g.deaths
+}
+
 func (g *GameData) IsGameClear() bool {
-	for _, e := range clearFlagItems {
+	for _, e := range fieldtype.ClearFlagItems {
 		if !g.itemGetFlags[e] {
 			return false
 		}
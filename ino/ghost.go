@@ -0,0 +1,160 @@
+package ino
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+	"github.com/hajimehoshi/go-inovation/ino/internal/draw"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/replay"
+)
+
+const (
+	// GHOST_BEST is the choice of the ghost of the personal best for the mode.
+	GHOST_BEST = ""
+
+	// GHOST_OFF is the choice of no ghost.
+	GHOST_OFF = "off"
+
+	GHOST_ALPHA = 0.4
+)
+
+// ghostDir returns the directory of the ghost files.
+func ghostDir() (string, error) {
+	dir, err := assets.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ghosts"), nil
+}
+
+// bestGhostPath returns the ghost file of the personal best on world in mode.
+func bestGhostPath(world *field.World, mode GameMode) (string, error) {
+	dir, err := ghostDir()
+	if err != nil {
+		return "", err
+	}
+	m := "normal"
+	if mode == GAMEMODE_LUNKER {
+		m = "lunker"
+	}
+	name, _ := world.Start()
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	return filepath.Join(dir, base+"-"+m+".inoghost"), nil
+}
+
+// ghostChoices returns the ghosts that can be chosen on the title:
+// the personal best, the ghost files in the ghost directory and no ghost.
+func ghostChoices() []string {
+	choices := []string{GHOST_BEST}
+	if dir, err := ghostDir(); err == nil {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.inoghost"))
+		sort.Strings(files)
+		choices = append(choices, files...)
+	}
+	return append(choices, GHOST_OFF)
+}
+
+// nextGhost returns the ghost choice d steps after current.
+func nextGhost(current string, d int) string {
+	choices := ghostChoices()
+	return choices[nextIndex(choices, current, d)]
+}
+
+// ghostLabel returns the label of the ghost choice on the title.
+func ghostLabel(choice string) string {
+	switch choice {
+	case GHOST_BEST:
+		return "Ghost: Best"
+	case GHOST_OFF:
+		return "Ghost: Off"
+	}
+	return "Ghost: " + strings.TrimSuffix(filepath.Base(choice), ".inoghost")
+}
+
+// loadGhost loads the ghost chosen on the title for a play of gameData on world.
+// It returns nil when there is no ghost to race.
+func loadGhost(choice string, world *field.World, gameData *GameData) *replay.Ghost {
+	path := choice
+	switch choice {
+	case GHOST_OFF:
+		return nil
+	case GHOST_BEST:
+		p, err := bestGhostPath(world, gameModeOf(gameData))
+		if err != nil {
+			return nil
+		}
+		path = p
+	}
+	g, err := replay.LoadGhost(path)
+	if errors.Is(err, fs.ErrNotExist) && choice == GHOST_BEST {
+		return nil
+	}
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	name, _ := world.Start()
+	if g.Field != filepath.Base(name) {
+		log.Printf("ino: %s is recorded on %s but the field is %s", path, g.Field, filepath.Base(name))
+		return nil
+	}
+	return g
+}
+
+// saveBestGhost saves g as the personal best if it is faster than the current one.
+func saveBestGhost(g *replay.Ghost, world *field.World) error {
+	path, err := bestGhostPath(world, GameMode(g.Mode))
+	if err != nil {
+		return err
+	}
+	best, err := replay.LoadGhost(path)
+	if err == nil && best.Time <= g.Time {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return replay.SaveGhost(path, g)
+}
+
+func newGhost(gameData *GameData, world *field.World) *replay.Ghost {
+	name, _ := world.Start()
+	return &replay.Ghost{
+		Mode:  int(gameModeOf(gameData)),
+		Field: filepath.Base(name),
+	}
+}
+
+// recordGhost appends the current state of p to g.
+func recordGhost(g *replay.Ghost, p *Player) {
+	name, anime := p.sprite()
+	g.Frames = append(g.Frames, replay.GhostFrame{
+		Field:  filepath.Base(p.fieldName),
+		X:      int(p.body.Position.X),
+		Y:      int(p.body.Position.Y),
+		Sprite: name,
+		Anime:  anime,
+	})
+}
+
+// drawGhost draws the frame of g translucently if it is in the field where p is.
+func drawGhost(screen *ebiten.Image, g *replay.Ghost, frame int, p *Player) {
+	if frame < 0 || len(g.Frames) <= frame {
+		return
+	}
+	f := g.Frames[frame]
+	if f.Sprite == "" || f.Field != filepath.Base(p.fieldName) {
+		return
+	}
+	v := p.view.ToScreenPosition(PositionF{X: float64(f.X), Y: float64(f.Y)})
+	draw.DrawSpriteWithAlpha(screen, f.Sprite, f.Anime, int(v.X), int(v.Y), GHOST_ALPHA)
+}
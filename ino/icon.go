@@ -3,6 +3,7 @@ package ino
 import (
 	"image"
 	_ "image/png"
+	"io/fs"
 	"path"
 	"path/filepath"
 
@@ -14,7 +15,7 @@ import (
 func setIcons() error {
 	const dir = "images/icons"
 
-	ents, err := assets.Assets.ReadDir(dir)
+	ents, err := fs.ReadDir(assets.FS, dir)
 	if err != nil {
 		return err
 	}
@@ -27,7 +28,7 @@ func setIcons() error {
 			continue
 		}
 
-		f, err := assets.Assets.Open(path.Join(dir, name))
+		f, err := assets.FS.Open(path.Join(dir, name))
 		if err != nil {
 			return err
 		}
@@ -4,5 +4,5 @@ import (
 	"embed"
 )
 
-//go:embed images/* sound/*
+//go:embed fields/* images/* sound/* sprites.json tiles.json
 var Assets embed.FS
@@ -0,0 +1,128 @@
+package assets
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// FS is the assets with the mods layered over the embedded ones.
+// The game should read the assets from FS instead of Assets.
+var FS fs.FS = Assets
+
+var mods []string
+
+// Mods returns the names of the active mods in the order they are layered.
+func Mods() []string {
+	return mods
+}
+
+// DataDir returns the directory of the user data like the mods, $XDG_DATA_HOME/inovation by default.
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "inovation"), nil
+	}
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "inovation"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "inovation"), nil
+}
+
+// DefaultModsDir returns the directory of the mods, $XDG_DATA_HOME/inovation/mods by default.
+func DefaultModsDir() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mods"), nil
+}
+
+// LoadMods layers the mods in dir over the embedded assets.
+//
+// Each subdirectory of dir is a mod, and has files at the same paths as the embedded assets,
+// like images/color/ino.png or sound/jump.wav. The mods are layered in the order of their names,
+// so a file in a later mod wins. A missing dir means no mods.
+func LoadMods(dir string) error {
+	ents, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		FS = Assets
+		mods = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	layers := []fs.FS{Assets}
+	for _, ent := range ents {
+		if !ent.IsDir() {
+			continue
+		}
+		names = append(names, ent.Name())
+		layers = append(layers, os.DirFS(filepath.Join(dir, ent.Name())))
+	}
+	FS = overlayFS(layers)
+	mods = names
+	return nil
+}
+
+// overlayFS is file systems layered in order. A file in a later layer hides the ones in the earlier layers,
+// and a directory lists the entries of all the layers.
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for i := len(o) - 1; i >= 0; i-- {
+		f, err := o[i].Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return f, err
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	found := false
+	entries := map[string]fs.DirEntry{}
+	for _, l := range o {
+		ents, err := fs.ReadDir(l, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, ent := range ents {
+			entries[ent.Name()] = ent
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	var ents []fs.DirEntry
+	for _, ent := range entries {
+		ents = append(ents, ent)
+	}
+	sort.Slice(ents, func(i, j int) bool {
+		return ents[i].Name() < ents[j].Name()
+	})
+	return ents, nil
+}
@@ -3,6 +3,7 @@ package audio
 import (
 	"bytes"
 	"io"
+	"io/fs"
 	"path"
 	"path/filepath"
 
@@ -28,7 +29,7 @@ func Mute() {
 func Load() error {
 	const dir = "sound"
 
-	ents, err := assets.Assets.ReadDir(dir)
+	ents, err := fs.ReadDir(assets.FS, dir)
 	if err != nil {
 		return err
 	}
@@ -40,11 +41,12 @@ func Load() error {
 			continue
 		}
 
-		f, err := assets.Assets.Open(path.Join(dir, name))
+		// Read the whole file since the stream keeps reading it after Load returns.
+		bs, err := fs.ReadFile(assets.FS, path.Join(dir, name))
 		if err != nil {
 			return err
 		}
-		defer f.Close()
+		f := bytes.NewReader(bs)
 
 		var s io.ReadSeeker
 		switch ext {
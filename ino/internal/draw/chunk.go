@@ -0,0 +1,119 @@
+package draw
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+)
+
+const (
+	chunkSize = 16 // チャンクの一辺のタイル数
+)
+
+// chunkCache is the static tiles of a field pre-rendered into chunks.
+// The animated tiles are drawn every frame instead. See field.Field.IsAnimated.
+type chunkCache struct {
+	field    *field.Field
+	revision int
+	chunks   map[image.Point]*ebiten.Image
+}
+
+// theChunkCache is the cache of the field drawn last.
+var theChunkCache *chunkCache
+
+func currentChunkCache(f *field.Field) *chunkCache {
+	if theChunkCache != nil && theChunkCache.field == f {
+		theChunkCache.invalidate()
+		return theChunkCache
+	}
+	if theChunkCache != nil {
+		theChunkCache.dispose()
+	}
+	theChunkCache = &chunkCache{
+		field:    f,
+		revision: f.Revision(),
+		chunks:   map[image.Point]*ebiten.Image{},
+	}
+	return theChunkCache
+}
+
+// invalidate discards the chunks that have tiles changed since the last frame.
+func (c *chunkCache) invalidate() {
+	for _, p := range c.field.ChangedSince(c.revision) {
+		cp := image.Pt(p.X/chunkSize, p.Y/chunkSize)
+		if img, ok := c.chunks[cp]; ok {
+			img.Deallocate()
+			delete(c.chunks, cp)
+		}
+	}
+	c.revision = c.field.Revision()
+}
+
+func (c *chunkCache) dispose() {
+	for _, img := range c.chunks {
+		img.Deallocate()
+	}
+	c.chunks = nil
+}
+
+// chunk returns the image of the chunk at (cx, cy) in chunks, rendering it if needed.
+func (c *chunkCache) chunk(cx, cy int, gameData field.GameData) *ebiten.Image {
+	if img, ok := c.chunks[image.Pt(cx, cy)]; ok {
+		return img
+	}
+	img := ebiten.NewImage(chunkSize*field.CHAR_SIZE, chunkSize*field.CHAR_SIZE)
+	for y := 0; y < chunkSize; y++ {
+		fy := cy*chunkSize + y
+		for x := 0; x < chunkSize; x++ {
+			fx := cx*chunkSize + x
+			if c.field.IsAnimated(fx, fy) || c.field.GetField(fx, fy).Tile().Zone != nil {
+				continue
+			}
+			sx, sy, ok := c.field.ImagePosition(fx, fy, gameData)
+			if !ok {
+				continue
+			}
+			Draw(img, "ino", x*field.CHAR_SIZE, y*field.CHAR_SIZE, sx, sy, field.CHAR_SIZE, field.CHAR_SIZE)
+		}
+	}
+	c.chunks[image.Pt(cx, cy)] = img
+	return img
+}
+
+// drawChunks draws the static tiles of f visible from the view position.
+func drawChunks(screen *ebiten.Image, f *field.Field, gameData field.GameData, viewPositionX, viewPositionY int) {
+	c := currentChunkCache(f)
+	const size = chunkSize * field.CHAR_SIZE
+	x0 := floorDiv(viewPositionX-ScreenWidth/2, size)
+	y0 := floorDiv(viewPositionY-ScreenHeight/2, size)
+	x1 := floorDiv(viewPositionX+ScreenWidth/2-1, size)
+	y1 := floorDiv(viewPositionY+ScreenHeight/2-1, size)
+	if x0 < 0 {
+		x0 = 0
+	}
+	if y0 < 0 {
+		y0 = 0
+	}
+	if last := (f.Width() - 1) / chunkSize; x1 > last {
+		x1 = last
+	}
+	if last := (f.Height() - 1) / chunkSize; y1 > last {
+		y1 = last
+	}
+	for cy := y0; cy <= y1; cy++ {
+		for cx := x0; cx <= x1; cx++ {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(cx*size-viewPositionX+ScreenWidth/2), float64(cy*size-viewPositionY+ScreenHeight/2))
+			screen.DrawImage(c.chunk(cx, cy, gameData), op)
+		}
+	}
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
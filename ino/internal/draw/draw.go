@@ -3,15 +3,12 @@ package draw
 import (
 	"image"
 	"image/color"
-	"path"
-	"path/filepath"
 	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"golang.org/x/text/language"
 
-	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
 	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
 	"github.com/hajimehoshi/go-inovation/ino/internal/font"
 	"github.com/hajimehoshi/go-inovation/ino/internal/input"
@@ -105,38 +102,6 @@ var (
 	images = map[string]*ebiten.Image{}
 )
 
-func LoadImages() error {
-	const dir = "images/color"
-
-	ents, err := assets.Assets.ReadDir(dir)
-	if err != nil {
-		return err
-	}
-
-	for _, ent := range ents {
-		name := ent.Name()
-		ext := filepath.Ext(name)
-		if ext != ".png" {
-			continue
-		}
-
-		f, err := assets.Assets.Open(path.Join(dir, name))
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
-		img, _, err := image.Decode(f)
-		if err != nil {
-			return err
-		}
-
-		key := name[:len(name)-len(ext)]
-		images[key] = ebiten.NewImageFromImage(img)
-	}
-	return nil
-}
-
 func Draw(screen *ebiten.Image, key string, px, py, sx, sy, sw, sh int) {
 	op := &ebiten.DrawImageOptions{}
 	op.GeoM.Translate(float64(px), float64(py))
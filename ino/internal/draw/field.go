@@ -0,0 +1,77 @@
+package draw
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+)
+
+// DrawField draws the field except the zones.
+//
+// The static tiles are pre-rendered into chunks, and only the animated tiles are drawn one by one.
+func DrawField(screen *ebiten.Image, f *field.Field, gameData field.GameData, viewPositionX, viewPositionY int) {
+	drawChunks(screen, f, gameData, viewPositionX, viewPositionY)
+	drawTiles(screen, f, gameData, viewPositionX, viewPositionY, func(x, y int) bool {
+		return f.IsAnimated(x, y) && f.GetField(x, y).Tile().Zone == nil
+	})
+	drawPlatforms(screen, f, viewPositionX, viewPositionY)
+}
+
+// drawFieldWithoutCache draws the field in the same way as DrawField without the chunks.
+func drawFieldWithoutCache(screen *ebiten.Image, f *field.Field, gameData field.GameData, viewPositionX, viewPositionY int) {
+	drawTiles(screen, f, gameData, viewPositionX, viewPositionY, func(x, y int) bool {
+		return f.GetField(x, y).Tile().Zone == nil
+	})
+	drawPlatforms(screen, f, viewPositionX, viewPositionY)
+}
+
+func drawPlatforms(screen *ebiten.Image, f *field.Field, viewPositionX, viewPositionY int) {
+
+	// 移動床
+	vx, vy := viewPositionX, viewPositionY
+	for _, p := range f.Platforms() {
+		px, py := p.Position()
+		sx, sy := field.TypeImagePosition(p.Type, f.Timer())
+		for i := 0; i < p.Width; i++ {
+			Draw(screen, "ino",
+				int(px)+i*field.CHAR_SIZE-vx+ScreenWidth/2,
+				int(py)-vy+ScreenHeight/2,
+				sx, sy, field.CHAR_SIZE, field.CHAR_SIZE)
+		}
+	}
+}
+
+// DrawZones draws the zones like water over the characters.
+func DrawZones(screen *ebiten.Image, f *field.Field, gameData field.GameData, viewPositionX, viewPositionY int) {
+	drawTiles(screen, f, gameData, viewPositionX, viewPositionY, func(x, y int) bool {
+		return f.GetField(x, y).Tile().Zone != nil
+	})
+}
+
+// drawTiles draws the visible tiles at (x, y) where filter(x, y) is true.
+func drawTiles(screen *ebiten.Image, f *field.Field, gameData field.GameData, viewPositionX, viewPositionY int, filter func(x, y int) bool) {
+	const (
+		graphicOffsetX = -16 - 16*2
+		graphicOffsetY = 8 - 16*2
+	)
+	vx, vy := viewPositionX, viewPositionY
+	ofs_x := field.CHAR_SIZE - vx%field.CHAR_SIZE
+	ofs_y := field.CHAR_SIZE - vy%field.CHAR_SIZE
+	for xx := -(ScreenWidth/field.CHAR_SIZE/2 + 2); xx < (ScreenWidth/field.CHAR_SIZE/2 + 2); xx++ {
+		fx := xx + vx/field.CHAR_SIZE
+		for yy := -(ScreenHeight/field.CHAR_SIZE/2 + 2); yy < (ScreenHeight/field.CHAR_SIZE/2 + 2); yy++ {
+			fy := yy + vy/field.CHAR_SIZE
+			if !filter(fx, fy) {
+				continue
+			}
+			sx, sy, ok := f.ImagePosition(fx, fy, gameData)
+			if !ok {
+				continue
+			}
+			Draw(screen, "ino",
+				(xx+12)*field.CHAR_SIZE+ofs_x+graphicOffsetX+(ScreenWidth-320)/2,
+				(yy+8)*field.CHAR_SIZE+ofs_y+graphicOffsetY+(ScreenHeight-240)/2,
+				sx, sy, field.CHAR_SIZE, field.CHAR_SIZE)
+		}
+	}
+}
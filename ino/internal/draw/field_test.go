@@ -0,0 +1,71 @@
+package draw
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+)
+
+// testGame runs the tests in the game loop, where images can be drawn.
+type testGame struct {
+	m    *testing.M
+	code int
+}
+
+func (g *testGame) Update() error {
+	g.code = g.m.Run()
+	return ebiten.Termination
+}
+
+func (g *testGame) Draw(screen *ebiten.Image) {
+}
+
+func (g *testGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return ScreenWidth, ScreenHeight
+}
+
+func TestMain(m *testing.M) {
+	if err := SetTheme(DefaultTheme); err != nil {
+		panic(err)
+	}
+	g := &testGame{m: m, code: 1}
+	if err := ebiten.RunGame(g); err != nil {
+		panic(err)
+	}
+	os.Exit(g.code)
+}
+
+type benchGameData struct{}
+
+func (benchGameData) IsHiddenSecret() bool {
+	return true
+}
+
+func benchmarkDrawField(b *testing.B, drawField func(screen *ebiten.Image, f *field.Field, gameData field.GameData, viewPositionX, viewPositionY int)) {
+	f, err := field.Load(assets.Assets, "fields/inovation.inofield")
+	if err != nil {
+		b.Fatal(err)
+	}
+	screen := ebiten.NewImage(ScreenWidth, ScreenHeight)
+	w, h := f.Width()*field.CHAR_SIZE, f.Height()*field.CHAR_SIZE
+
+	// 画面をスクロールしながら描く
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := ScreenWidth/2 + (i*3)%(w-ScreenWidth)
+		y := ScreenHeight/2 + (i*2)%(h-ScreenHeight)
+		drawField(screen, f, benchGameData{}, x, y)
+	}
+}
+
+func BenchmarkDrawField(b *testing.B) {
+	benchmarkDrawField(b, DrawField)
+}
+
+func BenchmarkDrawFieldWithoutCache(b *testing.B) {
+	benchmarkDrawField(b, drawFieldWithoutCache)
+}
@@ -0,0 +1,111 @@
+package draw
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+)
+
+// Sprite is a named image in a sprite sheet, with its animation frames.
+type Sprite struct {
+	// Name is the name used by the game code, like "player_left".
+	Name string `json:"name"`
+
+	// Image is the name of the sprite sheet without the extension, like "ino".
+	Image string `json:"image"`
+
+	// Size is the width and the height of a frame in pixels.
+	Size [2]int `json:"size"`
+
+	// Frames are the upper-left positions of the frames in the sprite sheet in pixels.
+	Frames [][2]int `json:"frames"`
+
+	// Interval is the number of the ticks for each frame of the animation.
+	// The default is 1.
+	Interval int `json:"interval"`
+}
+
+var sprites = map[string]*Sprite{}
+
+func loadSprites() error {
+	f, err := assets.FS.Open("sprites.json")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return LoadSprites(f, "sprites.json")
+}
+
+// LoadSprites replaces the sprite table with the one in r.
+// The table is a JSON array of Sprite. The sprite sheets must be loaded in advance.
+// filename is used only for error messages.
+func LoadSprites(r io.Reader, filename string) error {
+	var entries []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	ss := map[string]*Sprite{}
+	for i, e := range entries {
+		s := &Sprite{
+			Interval: 1,
+		}
+		if err := json.Unmarshal(e, s); err != nil {
+			return fmt.Errorf("%s: sprite #%d: %w", filename, i, err)
+		}
+		if s.Name == "" {
+			return fmt.Errorf("%s: sprite #%d: missing name", filename, i)
+		}
+		if _, ok := ss[s.Name]; ok {
+			return fmt.Errorf("%s: sprite #%d: duplicated sprite %q", filename, i, s.Name)
+		}
+		if _, ok := images[s.Image]; !ok {
+			return fmt.Errorf("%s: sprite #%d: unknown image %q", filename, i, s.Image)
+		}
+		if len(s.Frames) == 0 {
+			return fmt.Errorf("%s: sprite #%d: no frames", filename, i)
+		}
+		if s.Size[0] <= 0 || s.Size[1] <= 0 || s.Interval <= 0 {
+			return fmt.Errorf("%s: sprite #%d: size and interval must be positive", filename, i)
+		}
+		ss[s.Name] = s
+	}
+	sprites = ss
+	return nil
+}
+
+func sprite(name string) *Sprite {
+	s, ok := sprites[name]
+	if !ok {
+		panic(fmt.Sprintf("draw: unknown sprite %q", name))
+	}
+	return s
+}
+
+// AnimationFrame returns the frame of the sprite name at timer.
+func AnimationFrame(name string, timer int) int {
+	s := sprite(name)
+	return (timer / s.Interval) % len(s.Frames)
+}
+
+// DrawSprite draws the frame of the sprite name at (x, y).
+func DrawSprite(screen *ebiten.Image, name string, frame int, x, y int) {
+	s := sprite(name)
+	f := s.Frames[frame%len(s.Frames)]
+	Draw(screen, s.Image, x, y, f[0], f[1], s.Size[0], s.Size[1])
+}
+
+// DrawSpriteWithAlpha is like DrawSprite but draws the sprite translucently.
+func DrawSpriteWithAlpha(screen *ebiten.Image, name string, frame int, x, y int, alpha float64) {
+	s := sprite(name)
+	f := s.Frames[frame%len(s.Frames)]
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(x), float64(y))
+	op.ColorM.Scale(1, 1, 1, alpha)
+	screen.DrawImage(images[s.Image].SubImage(image.Rect(f[0], f[1], f[0]+s.Size[0], f[1]+s.Size[1])).(*ebiten.Image), op)
+}
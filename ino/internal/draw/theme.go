@@ -0,0 +1,135 @@
+package draw
+
+import (
+	"fmt"
+	"image"
+	_ "image/png"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+)
+
+// DefaultTheme is the theme used for the images missing in the other themes.
+const DefaultTheme = "color"
+
+// BuiltinThemes are the names of the themes in the assets.
+var BuiltinThemes = []string{"color", "mono"}
+
+var currentTheme string
+
+// Theme returns the name of the current theme.
+func Theme() string {
+	return currentTheme
+}
+
+func isBuiltinTheme(theme string) bool {
+	for _, t := range BuiltinThemes {
+		if t == theme {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTheme replaces the images with the ones of the theme.
+//
+// theme is the name of a built-in theme, or a directory that has PNG images like ino.png.
+// The images missing in the theme are taken from the default theme with a warning.
+// msg.png in a theme is taken as msg_ja.png, the messages in Japanese.
+func SetTheme(theme string) error {
+	imgs, err := decodeImages(assets.FS, path.Join("images", DefaultTheme))
+	if err != nil {
+		return err
+	}
+	if theme != DefaultTheme {
+		var themed map[string]image.Image
+		if isBuiltinTheme(theme) {
+			themed, err = decodeImages(assets.FS, path.Join("images", theme))
+		} else {
+			themed, err = decodeImages(os.DirFS(theme), ".")
+		}
+		if err != nil {
+			return fmt.Errorf("draw: theme %s: %w", theme, err)
+		}
+		// msg.png は日本語のメッセージ (msg_ja.png と同じ配置)
+		if img, ok := themed["msg"]; ok {
+			if _, ok := themed["msg_ja"]; !ok {
+				themed["msg_ja"] = img
+			}
+			delete(themed, "msg")
+		}
+		var keys []string
+		for key := range imgs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := themed[key]; !ok {
+				log.Printf("draw: theme %s has no %s.png; the one of the default theme is used", theme, key)
+			}
+		}
+		for key, img := range themed {
+			imgs[key] = img
+		}
+	}
+
+	for _, img := range images {
+		img.Deallocate()
+	}
+	images = map[string]*ebiten.Image{}
+	for key, img := range imgs {
+		images[key] = ebiten.NewImageFromImage(img)
+	}
+	currentTheme = theme
+
+	// 古い画像で描かれたチャンクを捨てる
+	if theChunkCache != nil {
+		theChunkCache.dispose()
+		theChunkCache = nil
+	}
+	return loadSprites()
+}
+
+// decodeImages decodes the PNG images in dir. The keys are the file names without the extension.
+func decodeImages(fsys fs.FS, dir string) (map[string]image.Image, error) {
+	ents, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	imgs := map[string]image.Image{}
+	for _, ent := range ents {
+		name := ent.Name()
+		ext := path.Ext(name)
+		if ext != ".png" {
+			continue
+		}
+
+		img, err := decodeImage(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		imgs[name[:len(name)-len(ext)]] = img
+	}
+	return imgs, nil
+}
+
+func decodeImage(fsys fs.FS, name string) (image.Image, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,69 @@
+package field
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+const (
+	CRUMBLE_SHAKE_TIME = 30  // 崩れるまで
+	CRUMBLE_GONE_TIME  = 180 // 復活するまで
+)
+
+// Crumble starts to crumble the crumbling block at (x, y) if it is intact.
+//
+// The block shakes for CRUMBLE_SHAKE_TIME frames, disappears, and reappears
+// after CRUMBLE_GONE_TIME frames.
+func (f *Field) Crumble(x, y int) {
+	if f.GetField(x, y) != fieldtype.FIELD_CRUMBLE {
+		return
+	}
+	p := image.Pt(x, y)
+	if _, ok := f.crumbles[p]; ok {
+		return
+	}
+	if f.crumbles == nil {
+		f.crumbles = map[image.Point]int{}
+	}
+	f.crumbles[p] = 0
+}
+
+// crumbled reports whether the crumbling block at (x, y) has disappeared.
+func (f *Field) crumbled(x, y int) bool {
+	t, ok := f.crumbles[image.Pt(x, y)]
+	return ok && t >= CRUMBLE_SHAKE_TIME
+}
+
+func (f *Field) updateCrumbles() {
+	for p, t := range f.crumbles {
+		t++
+		if t > CRUMBLE_SHAKE_TIME+CRUMBLE_GONE_TIME {
+			// 誰かがいる場所には復活しない
+			if f.occupied[p] {
+				continue
+			}
+			delete(f.crumbles, p)
+			continue
+		}
+		f.crumbles[p] = t
+	}
+}
+
+// crumbleImagePosition returns the upper-left position of the crumbling block at (x, y)
+// in the "ino" image. ok is false if the block is not crumbling.
+//
+// The frames of a crumbling block are in the column of the tile: intact, shaking (2 frames)
+// and about to disappear.
+func (f *Field) crumbleImagePosition(x, y int) (sx, sy int, ok bool) {
+	t, ok := f.crumbles[image.Pt(x, y)]
+	if !ok {
+		return 0, 0, false
+	}
+	frame := 1 + (t/3)%2
+	if t >= CRUMBLE_SHAKE_TIME*3/4 {
+		frame = 3
+	}
+	sx, sy = TypeImagePosition(fieldtype.FIELD_CRUMBLE, 0)
+	return sx, sy + frame*CHAR_SIZE, true
+}
@@ -1,46 +1,134 @@
 package field
 
 import (
-	"strings"
+	"image"
 
-	"github.com/hajimehoshi/ebiten/v2"
-
-	"github.com/hajimehoshi/go-inovation/ino/internal/draw"
 	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
 )
 
 const (
 	CHAR_SIZE = 16
-	maxFieldX = 128
-	maxFieldY = 128
 )
 
 type Field struct {
-	field [maxFieldX * maxFieldY]fieldtype.FieldType
-	timer int
+	field  []fieldtype.FieldType
+	width  int
+	height int
+	name   string
+	warps  map[image.Point]Warp
+	timer  int
+
+	platforms []*Platform
+
+	// crumbles are the frames since each crumbling block started to crumble.
+	crumbles map[image.Point]int
+
+	groups   map[image.Point]int
+	switches map[int]bool
+	// doors are the frames each door group has been opening, from 0 (closed) to DOOR_OPEN_TIME (open).
+	doors map[int]int
+
+	// occupied are the tiles where characters are until the next update.
+	occupied map[image.Point]bool
+
+	// revision is the number of the changes of the tiles, and revisions are the revisions
+	// when the tiles were changed last.
+	revision  int
+	revisions map[image.Point]int
 }
 
-func New(data string) *Field {
-	f := &Field{}
-	xm := strings.Split(data, "\n")
-	const decoder = " HUB~<>*I PabcdefghijklmnopqrzL@"
+// Warp is the destination of a warp tile.
+type Warp struct {
+	// Map is the level file of the destination relative to the level file of the warp.
+	// An empty Map means the same field.
+	Map string
+
+	// X and Y are the tile position of the destination.
+	X int
+	Y int
+}
+
+func New(width, height int) *Field {
+	return &Field{
+		field:  make([]fieldtype.FieldType, width*height),
+		width:  width,
+		height: height,
+	}
+}
 
-	for yy, line := range xm {
-		for xx, c := range line {
-			n := strings.IndexByte(decoder, byte(c))
-			f.field[yy*maxFieldX+xx] = fieldtype.FieldType(n)
+func (f *Field) Clone() *Field {
+	f2 := *f
+	f2.field = make([]fieldtype.FieldType, len(f.field))
+	copy(f2.field, f.field)
+	f2.warps = map[image.Point]Warp{}
+	for p, w := range f.warps {
+		f2.warps[p] = w
+	}
+	f2.crumbles = map[image.Point]int{}
+	for p, t := range f.crumbles {
+		f2.crumbles[p] = t
+	}
+	f2.groups = map[image.Point]int{}
+	for p, g := range f.groups {
+		f2.groups[p] = g
+	}
+	f2.switches = map[int]bool{}
+	for g, on := range f.switches {
+		f2.switches[g] = on
+	}
+	f2.doors = map[int]int{}
+	for g, t := range f.doors {
+		f2.doors[g] = t
+	}
+	f2.occupied = nil
+	for p := range f.occupied {
+		if f2.occupied == nil {
+			f2.occupied = map[image.Point]bool{}
 		}
+		f2.occupied[p] = true
+	}
+	f2.revisions = map[image.Point]int{}
+	for p, r := range f.revisions {
+		f2.revisions[p] = r
 	}
-	return f
+	f2.platforms = nil
+	for _, p := range f.platforms {
+		p2 := *p
+		f2.platforms = append(f2.platforms, &p2)
+	}
+	return &f2
+}
+
+func (f *Field) Name() string {
+	return f.name
+}
+
+func (f *Field) Width() int {
+	return f.width
+}
+
+func (f *Field) Height() int {
+	return f.height
 }
 
 func (f *Field) Update() {
 	f.timer++
+	f.updateCrumbles()
+	f.updateDoors()
+	f.occupied = nil
+	for _, p := range f.platforms {
+		p.update()
+	}
+}
+
+// Timer returns the number of the updates of the field.
+func (f *Field) Timer() int {
+	return f.timer
 }
 
 func (f *Field) GetStartPoint() (int, int) {
-	for yy := 0; yy < maxFieldY; yy++ {
-		for xx := 0; xx < maxFieldX; xx++ {
+	for yy := 0; yy < f.height; yy++ {
+		for xx := 0; xx < f.width; xx++ {
 			if f.GetField(xx, yy) == fieldtype.FIELD_ITEM_STARTPOINT {
 				x := xx * CHAR_SIZE
 				y := yy * CHAR_SIZE
@@ -52,85 +140,207 @@ func (f *Field) GetStartPoint() (int, int) {
 	panic("no start point")
 }
 
+// Find returns the positions of all the tiles of type t.
+func (f *Field) Find(t fieldtype.FieldType) []image.Point {
+	var ps []image.Point
+	for yy := 0; yy < f.height; yy++ {
+		for xx := 0; xx < f.width; xx++ {
+			if f.GetField(xx, yy) == t {
+				ps = append(ps, image.Pt(xx, yy))
+			}
+		}
+	}
+	return ps
+}
+
+func (f *Field) inField(x, y int) bool {
+	return 0 <= x && x < f.width && 0 <= y && y < f.height
+}
+
 func (f *Field) IsWall(x, y int) bool {
-	return f.field[y*maxFieldX+x] != fieldtype.FIELD_NONE &&
-		f.field[y*maxFieldX+x] != fieldtype.FIELD_HIDEPATH &&
-		f.field[y*maxFieldX+x] != fieldtype.FIELD_BAR &&
-		!f.IsItem(x, y)
+	t := f.GetField(x, y)
+	return t.Tile().Solid && !f.removed(x, y, t)
 }
+
 func (f *Field) IsRidable(x, y int) bool {
-	return f.field[y*maxFieldX+x] != fieldtype.FIELD_NONE &&
-		f.field[y*maxFieldX+x] != fieldtype.FIELD_HIDEPATH &&
-		!f.IsItem(x, y)
+	if f.isLadderTop(x, y) {
+		return true
+	}
+	t := f.GetField(x, y)
+	return t.Tile().Ridable && !f.removed(x, y, t)
+}
+
+// IsClimbable reports whether the tile at (x, y) is a ladder.
+func (f *Field) IsClimbable(x, y int) bool {
+	return f.GetField(x, y).Tile().Climbable
+}
+
+// isLadderTop reports whether the tile at (x, y) is the top of a ladder.
+func (f *Field) isLadderTop(x, y int) bool {
+	return f.IsClimbable(x, y) && !f.IsClimbable(x, y-1)
+}
+
+// removed reports whether the tile t at (x, y) is passable for now, like a crumbled block or an open door.
+func (f *Field) removed(x, y int, t fieldtype.FieldType) bool {
+	switch t {
+	case fieldtype.FIELD_CRUMBLE:
+		return f.crumbled(x, y)
+	case fieldtype.FIELD_DOOR:
+		return f.doorOpen(x, y)
+	}
+	return false
+}
+
+// Occupy tells that a character is at (x, y) until the next update.
+// Blocks don't appear at an occupied tile, like crumbled blocks and open doors.
+func (f *Field) Occupy(x, y int) {
+	if f.occupied == nil {
+		f.occupied = map[image.Point]bool{}
+	}
+	f.occupied[image.Pt(x, y)] = true
 }
 
 func (f *Field) IsSpike(x, y int) bool {
-	return f.field[y*maxFieldX+x] == fieldtype.FIELD_SPIKE
+	return f.GetField(x, y).Tile().Damage
 }
 
+// GetField returns the tile at (x, y).
+// Outside of the field is filled with invisible blocks.
 func (f *Field) GetField(x, y int) fieldtype.FieldType {
-	return f.field[y*maxFieldX+x]
+	if !f.inField(x, y) {
+		return fieldtype.FIELD_UNVISIBLE
+	}
+	return f.field[y*f.width+x]
+}
+
+func (f *Field) SetField(x, y int, t fieldtype.FieldType) {
+	if !f.inField(x, y) {
+		return
+	}
+	f.field[y*f.width+x] = t
+	f.revision++
+	if f.revisions == nil {
+		f.revisions = map[image.Point]int{}
+	}
+	f.revisions[image.Pt(x, y)] = f.revision
+	if t != fieldtype.FIELD_WARP {
+		delete(f.warps, image.Pt(x, y))
+	}
+	if !isGroupTile(t) {
+		delete(f.groups, image.Pt(x, y))
+	}
+	delete(f.crumbles, image.Pt(x, y))
+}
+
+// Revision returns the number of the changes of the tiles by SetField.
+func (f *Field) Revision() int {
+	return f.revision
+}
+
+// ChangedSince returns the positions of the tiles changed after the revision rev.
+func (f *Field) ChangedSince(rev int) []image.Point {
+	if rev >= f.revision {
+		return nil
+	}
+	var ps []image.Point
+	for p, r := range f.revisions {
+		if r > rev {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// Warp returns the destination of the warp tile at (x, y).
+// ok is false if the tile is not a warp or the warp has no destination.
+func (f *Field) Warp(x, y int) (w Warp, ok bool) {
+	if f.GetField(x, y) != fieldtype.FIELD_WARP {
+		return Warp{}, false
+	}
+	w, ok = f.warps[image.Pt(x, y)]
+	return
+}
+
+// SetWarp sets the destination of the warp tile at (x, y).
+func (f *Field) SetWarp(x, y int, w Warp) {
+	if f.GetField(x, y) != fieldtype.FIELD_WARP {
+		return
+	}
+	if f.warps == nil {
+		f.warps = map[image.Point]Warp{}
+	}
+	f.warps[image.Pt(x, y)] = w
+}
+
+// Warps returns the positions of the warp tiles that have destinations, in the reading order.
+func (f *Field) Warps() []image.Point {
+	var ps []image.Point
+	for _, p := range f.Find(fieldtype.FIELD_WARP) {
+		if _, ok := f.warps[p]; ok {
+			ps = append(ps, p)
+		}
+	}
+	return ps
 }
 
 func (f *Field) IsItem(x, y int) bool {
-	return f.field[y*maxFieldX+x] >= fieldtype.FIELD_ITEM_BORDER &&
-		f.field[y*maxFieldX+x] != fieldtype.FIELD_ITEM_STARTPOINT
+	return f.GetField(x, y).Tile().Item
 }
 
 func (f *Field) IsItemGettable(x, y int, gameData GameData) bool {
 	if !f.IsItem(x, y) {
 		return false
 	}
-	if f.field[y*maxFieldX+x] == fieldtype.FIELD_ITEM_OMEGA && gameData.IsHiddenSecret() {
+	if f.GetField(x, y).Tile().Hidden && gameData.IsHiddenSecret() {
 		return false
 	}
 	return true
 }
 
 func (f *Field) EraseField(x, y int) {
-	f.field[y*maxFieldX+x] = fieldtype.FIELD_NONE
+	f.SetField(x, y, fieldtype.FIELD_NONE)
 }
 
 type GameData interface {
 	IsHiddenSecret() bool
 }
 
-func (f *Field) Draw(screen *ebiten.Image, gameData GameData, viewPositionX, viewPositionY int) {
-	const (
-		graphicOffsetX = -16 - 16*2
-		graphicOffsetY = 8 - 16*2
-	)
-	vx, vy := viewPositionX, viewPositionY
-	ofs_x := CHAR_SIZE - vx%CHAR_SIZE
-	ofs_y := CHAR_SIZE - vy%CHAR_SIZE
-	for xx := -(draw.ScreenWidth/CHAR_SIZE/2 + 2); xx < (draw.ScreenWidth/CHAR_SIZE/2 + 2); xx++ {
-		fx := xx + vx/CHAR_SIZE
-		if fx < 0 || fx >= maxFieldX {
-			continue
-		}
-		for yy := -(draw.ScreenHeight/CHAR_SIZE/2 + 2); yy < (draw.ScreenHeight/CHAR_SIZE/2 + 2); yy++ {
-			fy := yy + vy/CHAR_SIZE
-			if fy < 0 || fy >= maxFieldY {
-				continue
-			}
-
-			gy := (f.timer / 10) % 4
-			gx := int(f.field[fy*maxFieldX+fx])
+// ImagePosition returns the upper-left position of the tile at (x, y) in the "ino" image.
+// ok is false if the tile is not visible.
+func (f *Field) ImagePosition(x, y int, gameData GameData) (sx, sy int, ok bool) {
+	if !f.inField(x, y) {
+		return 0, 0, false
+	}
+	if gameData.IsHiddenSecret() && f.GetField(x, y).Tile().Hidden {
+		return 0, 0, false
+	}
+	if f.crumbled(x, y) {
+		return 0, 0, false
+	}
+	if sx, sy, ok := f.crumbleImagePosition(x, y); ok {
+		return sx, sy, true
+	}
+	if isGroupTile(f.GetField(x, y)) {
+		sx, sy = f.groupImagePosition(x, y)
+		return sx, sy, true
+	}
 
-			if f.IsItem(fx, fy) {
-				gx -= (int(fieldtype.FIELD_ITEM_BORDER) + 1)
-				gy = 4 + gx/16
-				gx = gx % 16
-			}
+	sx, sy = TypeImagePosition(f.GetField(x, y), f.timer)
+	return sx, sy, true
+}
 
-			if gameData.IsHiddenSecret() && f.field[fy*maxFieldX+fx] == fieldtype.FIELD_ITEM_OMEGA {
-				continue
-			}
+// IsAnimated reports whether the image of the tile at (x, y) can change while the tile stays,
+// like animation frames, crumbling blocks, switches, doors and hidden items.
+func (f *Field) IsAnimated(x, y int) bool {
+	t := f.GetField(x, y)
+	tile := t.Tile()
+	return tile.Frames > 1 || tile.Hidden || t == fieldtype.FIELD_CRUMBLE || isGroupTile(t)
+}
 
-			draw.Draw(screen, "ino",
-				(xx+12)*CHAR_SIZE+ofs_x+graphicOffsetX+(draw.ScreenWidth-320)/2,
-				(yy+8)*CHAR_SIZE+ofs_y+graphicOffsetY+(draw.ScreenHeight-240)/2,
-				gx*16, gy*16, 16, 16)
-		}
-	}
+// TypeImagePosition returns the upper-left position of the tile t at timer in the "ino" image.
+func TypeImagePosition(t fieldtype.FieldType, timer int) (sx, sy int) {
+	tile := t.Tile()
+	gx := tile.Atlas[0]
+	gy := tile.Atlas[1] + (timer/fieldtype.TILE_ANIMATION_INTERVAL)%tile.Frames
+	return gx * CHAR_SIZE, gy * CHAR_SIZE
 }
@@ -0,0 +1,626 @@
+package field
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+// FileVersion is the latest version of the level file format.
+//
+// A level file consists of a header and a map, separated by a line "map".
+// Each header line is a keyword followed by its arguments:
+//
+//	# comment
+//	version 1
+//	name "INNO VATION! 2007"
+//	size 112 54
+//	legend 'B' block
+//	warp 10 5 "room2.inofield" 3 4
+//	platform bar 3 0.5 pingpong 20 10 20 4 28 4
+//	group 30 8 1
+//	map
+//
+// Each map line is a row of the field, and each rune is a tile defined by
+// the legend. Rows shorter than the width are padded with none.
+//
+// A warp line (since version 2) sets the destination of the warp tile at
+// (10, 5) to (3, 4) in room2.inofield. The file is relative to the level file,
+// and "" means the same field.
+//
+// A platform line (since version 3) adds a moving platform. The arguments are the tile type,
+// the width in tiles, the speed in pixels per frame, the mode (loop or pingpong), and two or
+// more tile positions of the left end of the platform. See Platform.
+//
+// A group line (since version 4) sets the group id of the switch or the door tile at (30, 8) to 1.
+// The switches and the doors without group lines are in the group 0.
+const FileVersion = 4
+
+// MaxSize is the maximum width and height of a field in tiles.
+// A larger size is rejected so that a broken file doesn't allocate a huge field.
+const MaxSize = 4096
+
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+type ErrorList []*Error
+
+func (e ErrorList) Error() string {
+	switch len(e) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", e[0], len(e)-1)
+}
+
+// Unwrap returns the errors in e so that the callers outside this package can print all of them.
+func (e ErrorList) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+type parser struct {
+	filename string
+	errs     ErrorList
+}
+
+func (p *parser) errorf(line, column int, format string, args ...interface{}) {
+	p.errs = append(p.errs, &Error{
+		File:   p.filename,
+		Line:   line,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	})
+}
+
+// Load parses the level file name in fsys.
+// Tiled maps (.tmx and .tmj) are also accepted. See tiled.go.
+func Load(fsys fs.FS, name string) (*Field, error) {
+	return load(name, func(name string) (io.ReadCloser, error) {
+		return fsys.Open(name)
+	}, func(rel string) string {
+		return path.Join(path.Dir(name), rel)
+	})
+}
+
+// LoadFile is like Load but reads the file name from the operating system.
+func LoadFile(name string) (*Field, error) {
+	return load(name, func(name string) (io.ReadCloser, error) {
+		return os.Open(name)
+	}, func(rel string) string {
+		return filepath.Join(filepath.Dir(name), filepath.FromSlash(rel))
+	})
+}
+
+// SaveFile writes f to the file name in the operating system.
+// The format is chosen by the extension in the same way as Load.
+func SaveFile(name string, f *Field) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".tmx":
+		err = WriteTMX(file, f, "ino.png")
+	case ".tmj", ".json":
+		err = WriteTMJ(file, f, "ino.png")
+	default:
+		err = Write(file, f)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// load loads the file name. resolve resolves a path relative to the file.
+func load(name string, open func(name string) (io.ReadCloser, error), resolve func(rel string) string) (*Field, error) {
+	f, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	openRel := func(rel string) (io.ReadCloser, error) {
+		return open(resolve(rel))
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".tmx":
+		return parseTMX(f, name, openRel)
+	case ".tmj", ".json":
+		return parseTMJ(f, name, openRel)
+	}
+	return Parse(f, name)
+}
+
+// Parse parses a level file. filename is used only for error messages.
+// If the file is malformed, Parse returns an ErrorList.
+func Parse(r io.Reader, filename string) (*Field, error) {
+	p := &parser{filename: filename}
+	f := &Field{}
+	legend := map[rune]fieldtype.FieldType{}
+	type warpLine struct {
+		lineno int
+		pos    image.Point
+		warp   Warp
+	}
+	var warps []warpLine
+	type platformLine struct {
+		lineno   int
+		platform Platform
+	}
+	var platforms []platformLine
+	type groupLine struct {
+		lineno int
+		pos    image.Point
+		id     int
+	}
+	var groups []groupLine
+
+	s := bufio.NewScanner(r)
+	lineno := 0
+	version := 0
+	sized := false
+	inMap := false
+	y := 0
+	for s.Scan() {
+		lineno++
+		line := s.Text()
+
+		if inMap {
+			if y >= f.height {
+				if strings.TrimSpace(line) != "" {
+					p.errorf(lineno, 1, "too many rows: the height is %d", f.height)
+				}
+				continue
+			}
+			x := 0
+			for i, c := range line {
+				col := utf8.RuneCountInString(line[:i]) + 1
+				t, ok := legend[c]
+				if !ok {
+					p.errorf(lineno, col, "unknown character %q", c)
+					x++
+					continue
+				}
+				if x >= f.width {
+					p.errorf(lineno, col, "row is longer than the width %d", f.width)
+					break
+				}
+				f.field[y*f.width+x] = t
+				x++
+			}
+			y++
+			continue
+		}
+
+		fields, err := splitHeaderLine(line)
+		if err != nil {
+			p.errorf(lineno, 0, "%v", err)
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		switch key, args := fields[0], fields[1:]; key {
+		case "version":
+			if len(args) != 1 {
+				p.errorf(lineno, 0, "version takes 1 argument")
+				continue
+			}
+			v, err := strconv.Atoi(args[0])
+			if err != nil || v <= 0 {
+				p.errorf(lineno, 0, "invalid version %q", args[0])
+				continue
+			}
+			if v > FileVersion {
+				p.errorf(lineno, 0, "unsupported version %d", v)
+				continue
+			}
+			version = v
+		case "name":
+			if len(args) != 1 {
+				p.errorf(lineno, 0, "name takes 1 argument")
+				continue
+			}
+			f.name = args[0]
+		case "size":
+			if len(args) != 2 {
+				p.errorf(lineno, 0, "size takes 2 arguments")
+				continue
+			}
+			w, err1 := strconv.Atoi(args[0])
+			h, err2 := strconv.Atoi(args[1])
+			if err1 != nil || err2 != nil || w <= 0 || h <= 0 {
+				p.errorf(lineno, 0, "invalid size %s %s", args[0], args[1])
+				continue
+			}
+			// MaxSize で抑えているので w*h は溢れない
+			if w > MaxSize || h > MaxSize {
+				p.errorf(lineno, 0, "too large size %dx%d (max %dx%d)", w, h, MaxSize, MaxSize)
+				continue
+			}
+			f.field = make([]fieldtype.FieldType, w*h)
+			f.width = w
+			f.height = h
+			sized = true
+		case "legend":
+			if len(args) != 2 {
+				p.errorf(lineno, 0, "legend takes 2 arguments")
+				continue
+			}
+			c := []rune(args[0])
+			if len(c) != 1 {
+				p.errorf(lineno, 0, "legend key must be one character: %q", args[0])
+				continue
+			}
+			t, ok := fieldtype.Parse(args[1])
+			if !ok {
+				p.errorf(lineno, 0, "unknown field type %q", args[1])
+				continue
+			}
+			if _, ok := legend[c[0]]; ok {
+				p.errorf(lineno, 0, "duplicated legend %q", c[0])
+				continue
+			}
+			legend[c[0]] = t
+		case "warp":
+			if len(args) != 5 {
+				p.errorf(lineno, 0, "warp takes 5 arguments")
+				continue
+			}
+			var ns [4]int
+			var err error
+			for i, a := range []string{args[0], args[1], args[3], args[4]} {
+				if ns[i], err = strconv.Atoi(a); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				p.errorf(lineno, 0, "invalid warp %s", strings.Join(args, " "))
+				continue
+			}
+			warps = append(warps, warpLine{
+				lineno: lineno,
+				pos:    image.Pt(ns[0], ns[1]),
+				warp: Warp{
+					Map: args[2],
+					X:   ns[2],
+					Y:   ns[3],
+				},
+			})
+		case "platform":
+			if len(args) < 8 || len(args)%2 != 0 {
+				p.errorf(lineno, 0, "platform takes a type, a width, a speed, a mode and two or more points")
+				continue
+			}
+			t, ok := fieldtype.Parse(args[0])
+			if !ok {
+				p.errorf(lineno, 0, "unknown field type %q", args[0])
+				continue
+			}
+			w, err := strconv.Atoi(args[1])
+			if err != nil || w <= 0 {
+				p.errorf(lineno, 0, "invalid platform width %q", args[1])
+				continue
+			}
+			speed, err := strconv.ParseFloat(args[2], 64)
+			if err != nil || speed <= 0 || math.IsInf(speed, 0) {
+				p.errorf(lineno, 0, "invalid platform speed %q", args[2])
+				continue
+			}
+			mode, ok := ParsePlatformMode(args[3])
+			if !ok {
+				p.errorf(lineno, 0, "unknown platform mode %q", args[3])
+				continue
+			}
+			var path []image.Point
+			for i := 4; i < len(args); i += 2 {
+				x, err1 := strconv.Atoi(args[i])
+				y, err2 := strconv.Atoi(args[i+1])
+				if err1 != nil || err2 != nil {
+					p.errorf(lineno, 0, "invalid platform point %s %s", args[i], args[i+1])
+					path = nil
+					break
+				}
+				path = append(path, image.Pt(x, y))
+			}
+			if path == nil {
+				continue
+			}
+			platforms = append(platforms, platformLine{
+				lineno: lineno,
+				platform: Platform{
+					Type:  t,
+					Width: w,
+					Path:  path,
+					Speed: speed,
+					Mode:  mode,
+				},
+			})
+		case "group":
+			if len(args) != 3 {
+				p.errorf(lineno, 0, "group takes 3 arguments")
+				continue
+			}
+			var ns [3]int
+			var err error
+			for i, a := range args {
+				if ns[i], err = strconv.Atoi(a); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				p.errorf(lineno, 0, "invalid group %s", strings.Join(args, " "))
+				continue
+			}
+			groups = append(groups, groupLine{
+				lineno: lineno,
+				pos:    image.Pt(ns[0], ns[1]),
+				id:     ns[2],
+			})
+		case "map":
+			if len(args) != 0 {
+				p.errorf(lineno, 0, "map takes no arguments")
+			}
+			if version == 0 {
+				p.errorf(lineno, 0, "missing version")
+			}
+			if !sized {
+				p.errorf(lineno, 0, "missing size")
+			}
+			if len(p.errs) > 0 {
+				return nil, p.errs
+			}
+			inMap = true
+		default:
+			p.errorf(lineno, 0, "unknown keyword %q", key)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !inMap {
+		p.errorf(lineno, 0, "missing map")
+	}
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+
+	for _, w := range warps {
+		if version < 2 {
+			p.errorf(w.lineno, 0, "warp requires version 2")
+			continue
+		}
+		if f.GetField(w.pos.X, w.pos.Y) != fieldtype.FIELD_WARP {
+			p.errorf(w.lineno, 0, "no warp tile at (%d, %d)", w.pos.X, w.pos.Y)
+			continue
+		}
+		if _, ok := f.Warp(w.pos.X, w.pos.Y); ok {
+			p.errorf(w.lineno, 0, "duplicated warp at (%d, %d)", w.pos.X, w.pos.Y)
+			continue
+		}
+		f.SetWarp(w.pos.X, w.pos.Y, w.warp)
+	}
+	for _, l := range platforms {
+		if version < 3 {
+			p.errorf(l.lineno, 0, "platform requires version 3")
+			continue
+		}
+		if !l.platform.Type.Tile().Ridable {
+			p.errorf(l.lineno, 0, "platform type %s is not ridable", l.platform.Type)
+			continue
+		}
+		ok := true
+		for _, pt := range l.platform.Path {
+			if !f.inField(pt.X, pt.Y) || !f.inField(pt.X+l.platform.Width-1, pt.Y) {
+				p.errorf(l.lineno, 0, "platform point (%d, %d) is out of the field", pt.X, pt.Y)
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		f.AddPlatform(l.platform)
+	}
+	seen := map[image.Point]bool{}
+	for _, g := range groups {
+		if version < 4 {
+			p.errorf(g.lineno, 0, "group requires version 4")
+			continue
+		}
+		if !isGroupTile(f.GetField(g.pos.X, g.pos.Y)) {
+			p.errorf(g.lineno, 0, "no switch or door tile at (%d, %d)", g.pos.X, g.pos.Y)
+			continue
+		}
+		if seen[g.pos] {
+			p.errorf(g.lineno, 0, "duplicated group at (%d, %d)", g.pos.X, g.pos.Y)
+			continue
+		}
+		seen[g.pos] = true
+		f.SetGroup(g.pos.X, g.pos.Y, g.id)
+	}
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+	return f, nil
+}
+
+// splitHeaderLine splits a header line into words. A word is either a bare
+// word, or a Go-style quoted string or rune literal. A '#' outside a quote
+// starts a comment.
+func splitHeaderLine(line string) ([]string, error) {
+	var words []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" || line[0] == '#' {
+			return words, nil
+		}
+		switch q := line[0]; q {
+		case '"', '\'':
+			end := 1
+			for end < len(line) && line[end] != q {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			w, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted word %s", line[:end+1])
+			}
+			words = append(words, w)
+			line = line[end+1:]
+		default:
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			words = append(words, line[:end])
+			line = line[end:]
+		}
+	}
+}
+
+// legendRunes are the characters used by Write. These are the same as the built-in field.
+var legendRunes = map[fieldtype.FieldType]rune{
+	fieldtype.FIELD_NONE:            ' ',
+	fieldtype.FIELD_HIDEPATH:        'H',
+	fieldtype.FIELD_UNVISIBLE:       'U',
+	fieldtype.FIELD_BLOCK:           'B',
+	fieldtype.FIELD_BAR:             '~',
+	fieldtype.FIELD_SCROLL_L:        '<',
+	fieldtype.FIELD_SCROLL_R:        '>',
+	fieldtype.FIELD_SPIKE:           '*',
+	fieldtype.FIELD_SLIP:            'I',
+	fieldtype.FIELD_WARP:            'W',
+	fieldtype.FIELD_CHECKPOINT:      'C',
+	fieldtype.FIELD_ENEMY_PATROL:    'E',
+	fieldtype.FIELD_ENEMY_BOUNCE:    'J',
+	fieldtype.FIELD_ENEMY_DROP:      'D',
+	fieldtype.FIELD_CRUMBLE:         'X',
+	fieldtype.FIELD_SWITCH:          'S',
+	fieldtype.FIELD_DOOR:            'G',
+	fieldtype.FIELD_WATER:           '=',
+	fieldtype.FIELD_LOWGRAVITY:      '.',
+	fieldtype.FIELD_WIND_L:          '(',
+	fieldtype.FIELD_WIND_R:          ')',
+	fieldtype.FIELD_LADDER:          '|',
+	fieldtype.FIELD_ITEM_POWERUP:    'P',
+	fieldtype.FIELD_ITEM_FUJI:       'a',
+	fieldtype.FIELD_ITEM_BUSHI:      'b',
+	fieldtype.FIELD_ITEM_APPLE:      'c',
+	fieldtype.FIELD_ITEM_V:          'd',
+	fieldtype.FIELD_ITEM_TAKA:       'e',
+	fieldtype.FIELD_ITEM_SHUOLDER:   'f',
+	fieldtype.FIELD_ITEM_DAGGER:     'g',
+	fieldtype.FIELD_ITEM_KATAKATA:   'h',
+	fieldtype.FIELD_ITEM_NASU:       'i',
+	fieldtype.FIELD_ITEM_BONUS:      'j',
+	fieldtype.FIELD_ITEM_NURSE:      'k',
+	fieldtype.FIELD_ITEM_NAZUNA:     'l',
+	fieldtype.FIELD_ITEM_GAMEHELL:   'm',
+	fieldtype.FIELD_ITEM_GUNDAM:     'n',
+	fieldtype.FIELD_ITEM_POED:       'o',
+	fieldtype.FIELD_ITEM_MILESTONE:  'p',
+	fieldtype.FIELD_ITEM_1YEN:       'q',
+	fieldtype.FIELD_ITEM_TRIANGLE:   'r',
+	fieldtype.FIELD_ITEM_OMEGA:      'z',
+	fieldtype.FIELD_ITEM_LIFE:       'L',
+	fieldtype.FIELD_ITEM_STARTPOINT: '@',
+}
+
+// Write writes f as a level file.
+func Write(w io.Writer, f *Field) error {
+	var types []fieldtype.FieldType
+	for t := range legendRunes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+
+	// Use the oldest version that can represent the field.
+	version := 1
+	warps := f.Warps()
+	if len(warps) > 0 {
+		version = 2
+	}
+	platforms := f.Platforms()
+	if len(platforms) > 0 {
+		version = 3
+	}
+	groups := f.Groups()
+	if len(groups) > 0 {
+		version = 4
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "version %d\n", version)
+	fmt.Fprintf(bw, "name %q\n", f.name)
+	fmt.Fprintf(bw, "size %d %d\n", f.width, f.height)
+	for _, t := range types {
+		fmt.Fprintf(bw, "legend %q %s\n", legendRunes[t], t)
+	}
+	for _, p := range warps {
+		wp, _ := f.Warp(p.X, p.Y)
+		fmt.Fprintf(bw, "warp %d %d %q %d %d\n", p.X, p.Y, wp.Map, wp.X, wp.Y)
+	}
+	for _, p := range platforms {
+		fmt.Fprintf(bw, "platform %s %d %s %s", p.Type, p.Width, strconv.FormatFloat(p.Speed, 'g', -1, 64), p.Mode)
+		for _, pt := range p.Path {
+			fmt.Fprintf(bw, " %d %d", pt.X, pt.Y)
+		}
+		fmt.Fprintln(bw)
+	}
+	for _, p := range groups {
+		fmt.Fprintf(bw, "group %d %d %d\n", p.X, p.Y, f.Group(p.X, p.Y))
+	}
+	fmt.Fprintln(bw, "map")
+	for y := 0; y < f.height; y++ {
+		var line []rune
+		for x := 0; x < f.width; x++ {
+			c, ok := legendRunes[f.GetField(x, y)]
+			if !ok {
+				return fmt.Errorf("field: invalid field type %d at (%d, %d)", f.GetField(x, y), x, y)
+			}
+			line = append(line, c)
+		}
+		fmt.Fprintln(bw, strings.TrimRight(string(line), " "))
+	}
+	return bw.Flush()
+}
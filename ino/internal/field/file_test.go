@@ -0,0 +1,152 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+func TestLoadBundledField(t *testing.T) {
+	f, err := Load(os.DirFS("../assets"), "fields/inovation.inofield")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.Name(), "INNO VATION! 2007"; got != want {
+		t.Errorf("name: got %q, want %q", got, want)
+	}
+	if f.Width() != 112 || f.Height() != 54 {
+		t.Errorf("size: got %dx%d, want 112x54", f.Width(), f.Height())
+	}
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	const name = "fields/inovation.inofield"
+	f, err := Load(os.DirFS("../assets"), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	written := buf.String()
+	f2, err := Parse(&buf, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f2, f) {
+		t.Errorf("the field differs after a round trip")
+	}
+
+	var buf2 bytes.Buffer
+	if err := Write(&buf2, f2); err != nil {
+		t.Fatal(err)
+	}
+	if buf2.String() != written {
+		t.Errorf("the written file differs after a round trip")
+	}
+}
+
+// The legend and the map of the level files in TestParseErrors, which is 3x2 and has a warp tile at (1, 0)
+// and a switch tile at (2, 1).
+const (
+	testLegend = "legend '.' none\nlegend '#' block\nlegend 'W' warp\nlegend 'S' switch\n"
+	testMap    = "map\n.W#\n#.S\n"
+)
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		line   int
+		msg    string
+	}{
+		{"version/args", "version\nsize 3 2\n" + testLegend, 1, "version takes 1 argument"},
+		{"version/invalid", "version 0\nsize 3 2\n" + testLegend, 1, `invalid version "0"`},
+		{"version/unsupported", "version 99\nsize 3 2\n" + testLegend, 1, "unsupported version 99"},
+		{"version/missing", "size 3 2\n" + testLegend, 6, "missing version"},
+
+		{"size/args", "version 1\nsize 3\n" + testLegend, 2, "size takes 2 arguments"},
+		{"size/invalid", "version 1\nsize 3 -2\n" + testLegend, 2, "invalid size 3 -2"},
+		{"size/large", "version 1\nsize 3 4097\n" + testLegend, 2, "too large size 3x4097"},
+		{"size/overflow", "version 1\nsize 9223372036854775807 9223372036854775807\n" + testLegend, 2, "too large size"},
+		{"size/missing", "version 1\n" + testLegend, 6, "missing size"},
+
+		{"legend/args", "version 1\nsize 3 2\nlegend '.'\n" + testLegend, 3, "legend takes 2 arguments"},
+		{"legend/key", "version 1\nsize 3 2\nlegend \"ab\" none\n" + testLegend, 3, "legend key must be one character"},
+		{"legend/type", "version 1\nsize 3 2\nlegend 'x' nothing\n" + testLegend, 3, `unknown field type "nothing"`},
+		{"legend/duplicated", "version 1\nsize 3 2\n" + testLegend + "legend '.' block\n", 7, "duplicated legend '.'"},
+
+		{"warp/args", "version 2\nsize 3 2\n" + testLegend + "warp 1 0 \"\"\n", 7, "warp takes 5 arguments"},
+		{"warp/invalid", "version 2\nsize 3 2\n" + testLegend + "warp 1 0 \"\" x 1\n", 7, "invalid warp"},
+		{"warp/version", "version 1\nsize 3 2\n" + testLegend + "warp 1 0 \"\" 2 1\n", 7, "warp requires version 2"},
+		{"warp/tile", "version 2\nsize 3 2\n" + testLegend + "warp 0 0 \"\" 2 1\n", 7, "no warp tile at (0, 0)"},
+		{"warp/duplicated", "version 2\nsize 3 2\n" + testLegend + "warp 1 0 \"\" 2 1\nwarp 1 0 \"\" 0 1\n", 8, "duplicated warp at (1, 0)"},
+
+		{"platform/args", "version 3\nsize 3 2\n" + testLegend + "platform bar 1 0.5 loop 0 1\n", 7, "platform takes"},
+		{"platform/type", "version 3\nsize 3 2\n" + testLegend + "platform nothing 1 0.5 loop 0 1 1 1\n", 7, `unknown field type "nothing"`},
+		{"platform/width", "version 3\nsize 3 2\n" + testLegend + "platform bar 0 0.5 loop 0 1 1 1\n", 7, `invalid platform width "0"`},
+		{"platform/speed", "version 3\nsize 3 2\n" + testLegend + "platform bar 1 -1 loop 0 1 1 1\n", 7, `invalid platform speed "-1"`},
+		{"platform/mode", "version 3\nsize 3 2\n" + testLegend + "platform bar 1 0.5 bounce 0 1 1 1\n", 7, `unknown platform mode "bounce"`},
+		{"platform/point", "version 3\nsize 3 2\n" + testLegend + "platform bar 1 0.5 loop 0 1 1 y\n", 7, "invalid platform point 1 y"},
+		{"platform/version", "version 2\nsize 3 2\n" + testLegend + "platform bar 1 0.5 loop 0 1 1 1\n", 7, "platform requires version 3"},
+		{"platform/ridable", "version 3\nsize 3 2\n" + testLegend + "platform none 1 0.5 loop 0 1 1 1\n", 7, "is not ridable"},
+		{"platform/out", "version 3\nsize 3 2\n" + testLegend + "platform bar 2 0.5 loop 0 1 2 1\n", 7, "platform point (2, 1) is out of the field"},
+
+		{"group/args", "version 4\nsize 3 2\n" + testLegend + "group 2 1\n", 7, "group takes 3 arguments"},
+		{"group/invalid", "version 4\nsize 3 2\n" + testLegend + "group 2 1 x\n", 7, "invalid group 2 1 x"},
+		{"group/version", "version 3\nsize 3 2\n" + testLegend + "group 2 1 1\n", 7, "group requires version 4"},
+		{"group/tile", "version 4\nsize 3 2\n" + testLegend + "group 0 0 1\n", 7, "no switch or door tile at (0, 0)"},
+		{"group/duplicated", "version 4\nsize 3 2\n" + testLegend + "group 2 1 1\ngroup 2 1 2\n", 8, "duplicated group at (2, 1)"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(c.header+testMap), "test.inofield")
+			var errs ErrorList
+			if !errors.As(err, &errs) {
+				t.Fatalf("got %v, want an ErrorList", err)
+			}
+			e := errs[0]
+			if e.File != "test.inofield" || e.Line != c.line || !strings.Contains(e.Msg, c.msg) {
+				t.Errorf("got %v, want test.inofield:%d: %s", e, c.line, c.msg)
+			}
+		})
+	}
+
+	if _, err := Parse(strings.NewReader("version 1\nsize 3 2\n"+testLegend+testMap), "test.inofield"); err != nil {
+		t.Errorf("the valid file: %v", err)
+	}
+}
+
+func TestErrorListUnwrap(t *testing.T) {
+	_, err := Parse(strings.NewReader("version 1\nsize 3\nlegend 'x' nothing\n"+testLegend+testMap), "test.inofield")
+	var errs interface{ Unwrap() []error }
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %v, want errors with Unwrap", err)
+	}
+	if got := len(errs.Unwrap()); got != 3 {
+		t.Errorf("got %d errors, want 3: %v", got, errs.Unwrap())
+	}
+}
+
+func TestParseLargeField(t *testing.T) {
+	f, err := Parse(strings.NewReader("version 1\nsize 300 200\n"+testLegend+testMap), "test.inofield")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Width() != 300 || f.Height() != 200 {
+		t.Errorf("size: got %dx%d, want 300x200", f.Width(), f.Height())
+	}
+	if got := f.GetField(2, 0); got != fieldtype.FIELD_BLOCK {
+		t.Errorf("(2, 0): got %v, want %v", got, fieldtype.FIELD_BLOCK)
+	}
+	if got := f.GetField(299, 199); got != fieldtype.FIELD_NONE {
+		t.Errorf("(299, 199): got %v, want %v", got, fieldtype.FIELD_NONE)
+	}
+}
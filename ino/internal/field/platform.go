@@ -0,0 +1,139 @@
+package field
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+// PlatformMode is how a moving platform goes along its path.
+type PlatformMode int
+
+const (
+	// PlatformLoop goes back to the first point after the last point.
+	PlatformLoop PlatformMode = iota
+
+	// PlatformPingPong goes back and forth along the path.
+	PlatformPingPong
+)
+
+var platformModeNames = map[PlatformMode]string{
+	PlatformLoop:     "loop",
+	PlatformPingPong: "pingpong",
+}
+
+func (m PlatformMode) String() string {
+	if n, ok := platformModeNames[m]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// ParsePlatformMode returns the platform mode named name.
+func ParsePlatformMode(name string) (PlatformMode, bool) {
+	for m, n := range platformModeNames {
+		if n == name {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// Platform is a moving platform. A platform is a row of tiles that moves along its path.
+//
+// The player can stand on a platform like on a bar, and is carried by it.
+// The player can jump through a platform from below and drop through it.
+type Platform struct {
+	// Type is the tile of the platform. It must be ridable, and its floor effects like
+	// friction and conveyor apply to the player on the platform.
+	Type fieldtype.FieldType
+
+	// Width is the number of tiles.
+	Width int
+
+	// Path is the tile positions of the left end of the platform.
+	Path []image.Point
+
+	// Speed is the number of pixels the platform moves in a frame.
+	Speed float64
+
+	Mode PlatformMode
+
+	x    float64
+	y    float64
+	dx   float64
+	dy   float64
+	next int
+	step int
+}
+
+func (p *Platform) reset() {
+	p.x = float64(p.Path[0].X * CHAR_SIZE)
+	p.y = float64(p.Path[0].Y * CHAR_SIZE)
+	p.dx = 0
+	p.dy = 0
+	p.next = 1 % len(p.Path)
+	p.step = 1
+}
+
+// Position returns the upper-left position of the platform in pixels.
+func (p *Platform) Position() (x, y float64) {
+	return p.x, p.y
+}
+
+// Delta returns how many pixels the platform moved in the last update.
+func (p *Platform) Delta() (dx, dy float64) {
+	return p.dx, p.dy
+}
+
+func (p *Platform) advance() {
+	if p.Mode == PlatformPingPong {
+		if n := p.next + p.step; n < 0 || n >= len(p.Path) {
+			p.step = -p.step
+		}
+		p.next += p.step
+		return
+	}
+	p.next = (p.next + 1) % len(p.Path)
+}
+
+func (p *Platform) update() {
+	p.dx = 0
+	p.dy = 0
+	if len(p.Path) < 2 {
+		return
+	}
+	rest := p.Speed
+	// Points at the same position are skipped, but don't loop forever when all the points are the same.
+	for i := 0; rest > 0 && i <= 2*len(p.Path); i++ {
+		vx := float64(p.Path[p.next].X*CHAR_SIZE) - p.x
+		vy := float64(p.Path[p.next].Y*CHAR_SIZE) - p.y
+		d := math.Hypot(vx, vy)
+		if d > rest {
+			vx *= rest / d
+			vy *= rest / d
+			d = rest
+		} else {
+			p.advance()
+		}
+		p.x += vx
+		p.y += vy
+		p.dx += vx
+		p.dy += vy
+		rest -= d
+	}
+}
+
+// AddPlatform adds a moving platform at the first point of its path.
+// The path must not be empty.
+func (f *Field) AddPlatform(p Platform) {
+	p.Path = append([]image.Point(nil), p.Path...)
+	p.reset()
+	f.platforms = append(f.platforms, &p)
+}
+
+// Platforms returns the moving platforms in the field.
+func (f *Field) Platforms() []*Platform {
+	return f.platforms
+}
@@ -0,0 +1,246 @@
+package field
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+// fieldJSON is a field with its states in the middle of a play, like erased items and open doors.
+// The tiles are named so that the states are kept even if the field types are renumbered.
+type fieldJSON struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+
+	// Types are the names of the tiles in the field, and Tiles are the indices of Types row by row.
+	Types []string `json:"types"`
+	Tiles []int    `json:"tiles"`
+
+	Warps     []warpJSON     `json:"warps,omitempty"`
+	Groups    []valueJSON    `json:"groups,omitempty"`
+	Platforms []platformJSON `json:"platforms,omitempty"`
+
+	Timer    int          `json:"timer"`
+	Crumbles []valueJSON  `json:"crumbles,omitempty"`
+	Switches map[int]bool `json:"switches,omitempty"`
+	Doors    map[int]int  `json:"doors,omitempty"`
+	Occupied []valueJSON  `json:"occupied,omitempty"`
+}
+
+type warpJSON struct {
+	X   int    `json:"x"`
+	Y   int    `json:"y"`
+	Map string `json:"map,omitempty"`
+	ToX int    `json:"tox"`
+	ToY int    `json:"toy"`
+}
+
+// valueJSON is a value at a tile.
+type valueJSON struct {
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	Value int `json:"value,omitempty"`
+}
+
+type platformJSON struct {
+	Type  string   `json:"type"`
+	Width int      `json:"width"`
+	Path  [][2]int `json:"path"`
+	Speed float64  `json:"speed"`
+	Mode  string   `json:"mode"`
+
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	DX   float64 `json:"dx"`
+	DY   float64 `json:"dy"`
+	Next int     `json:"next"`
+	Step int     `json:"step"`
+}
+
+func valuesJSON(m map[image.Point]int) []valueJSON {
+	var vs []valueJSON
+	for p, v := range m {
+		vs = append(vs, valueJSON{X: p.X, Y: p.Y, Value: v})
+	}
+	return vs
+}
+
+// MarshalJSON encodes the field with its current states.
+func (f *Field) MarshalJSON() ([]byte, error) {
+	j := fieldJSON{
+		Name:     f.name,
+		Width:    f.width,
+		Height:   f.height,
+		Groups:   valuesJSON(f.groups),
+		Timer:    f.timer,
+		Crumbles: valuesJSON(f.crumbles),
+		Switches: f.switches,
+		Doors:    f.doors,
+	}
+
+	indices := map[fieldtype.FieldType]int{}
+	for _, t := range f.field {
+		i, ok := indices[t]
+		if !ok {
+			i = len(j.Types)
+			indices[t] = i
+			j.Types = append(j.Types, t.String())
+		}
+		j.Tiles = append(j.Tiles, i)
+	}
+	for p, w := range f.warps {
+		j.Warps = append(j.Warps, warpJSON{X: p.X, Y: p.Y, Map: w.Map, ToX: w.X, ToY: w.Y})
+	}
+	for p := range f.occupied {
+		j.Occupied = append(j.Occupied, valueJSON{X: p.X, Y: p.Y})
+	}
+	for _, p := range f.platforms {
+		pj := platformJSON{
+			Type:  p.Type.String(),
+			Width: p.Width,
+			Speed: p.Speed,
+			Mode:  p.Mode.String(),
+			X:     p.x,
+			Y:     p.y,
+			DX:    p.dx,
+			DY:    p.dy,
+			Next:  p.next,
+			Step:  p.step,
+		}
+		for _, pt := range p.Path {
+			pj.Path = append(pj.Path, [2]int{pt.X, pt.Y})
+		}
+		j.Platforms = append(j.Platforms, pj)
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes the field encoded by MarshalJSON.
+// When f is a field already, the tiles that differ are counted as changed by SetField.
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var j fieldJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Width <= 0 || j.Height <= 0 || j.Width > MaxSize || j.Height > MaxSize || len(j.Tiles) != j.Width*j.Height {
+		return fmt.Errorf("field: %d tiles for the size %dx%d", len(j.Tiles), j.Width, j.Height)
+	}
+
+	types := make([]fieldtype.FieldType, len(j.Types))
+	for i, name := range j.Types {
+		t, ok := fieldtype.Parse(name)
+		if !ok {
+			return fmt.Errorf("field: unknown tile %q", name)
+		}
+		types[i] = t
+	}
+
+	f2 := New(j.Width, j.Height)
+	f2.name = j.Name
+	f2.timer = j.Timer
+	for i, t := range j.Tiles {
+		if t < 0 || t >= len(types) {
+			return fmt.Errorf("field: invalid tile index %d", t)
+		}
+		f2.field[i] = types[t]
+	}
+	for _, w := range j.Warps {
+		if !f2.inField(w.X, w.Y) {
+			return fmt.Errorf("field: warp (%d, %d) is out of the field", w.X, w.Y)
+		}
+		if f2.warps == nil {
+			f2.warps = map[image.Point]Warp{}
+		}
+		f2.warps[image.Pt(w.X, w.Y)] = Warp{Map: w.Map, X: w.ToX, Y: w.ToY}
+	}
+	for _, g := range j.Groups {
+		if !f2.inField(g.X, g.Y) {
+			return fmt.Errorf("field: group (%d, %d) is out of the field", g.X, g.Y)
+		}
+		if f2.groups == nil {
+			f2.groups = map[image.Point]int{}
+		}
+		f2.groups[image.Pt(g.X, g.Y)] = g.Value
+	}
+	for _, c := range j.Crumbles {
+		if f2.crumbles == nil {
+			f2.crumbles = map[image.Point]int{}
+		}
+		f2.crumbles[image.Pt(c.X, c.Y)] = c.Value
+	}
+	for _, o := range j.Occupied {
+		f2.Occupy(o.X, o.Y)
+	}
+	if j.Switches != nil {
+		f2.switches = j.Switches
+	}
+	if j.Doors != nil {
+		f2.doors = j.Doors
+	}
+	for _, pj := range j.Platforms {
+		t, ok := fieldtype.Parse(pj.Type)
+		if !ok {
+			return fmt.Errorf("field: unknown tile %q", pj.Type)
+		}
+		if !t.Tile().Ridable {
+			return fmt.Errorf("field: platform type %s is not ridable", t)
+		}
+		if pj.Width <= 0 {
+			return fmt.Errorf("field: invalid platform width %d", pj.Width)
+		}
+		if pj.Speed <= 0 || math.IsInf(pj.Speed, 0) {
+			return fmt.Errorf("field: invalid platform speed %v", pj.Speed)
+		}
+		m, ok := ParsePlatformMode(pj.Mode)
+		if !ok {
+			return fmt.Errorf("field: unknown platform mode %q", pj.Mode)
+		}
+		if len(pj.Path) == 0 || pj.Next < 0 || pj.Next >= len(pj.Path) {
+			return fmt.Errorf("field: invalid platform path")
+		}
+		p := &Platform{
+			Type:  t,
+			Width: pj.Width,
+			Speed: pj.Speed,
+			Mode:  m,
+			x:     pj.X,
+			y:     pj.Y,
+			dx:    pj.DX,
+			dy:    pj.DY,
+			next:  pj.Next,
+			step:  pj.Step,
+		}
+		for _, pt := range pj.Path {
+			if !f2.inField(pt[0], pt[1]) || !f2.inField(pt[0]+pj.Width-1, pt[1]) {
+				return fmt.Errorf("field: platform point (%d, %d) is out of the field", pt[0], pt[1])
+			}
+			p.Path = append(p.Path, image.Pt(pt[0], pt[1]))
+		}
+		f2.platforms = append(f2.platforms, p)
+	}
+
+	// 描画のキャッシュのために変わったタイルを記録する
+	if f.field != nil {
+		f2.revision = f.revision
+		f2.revisions = f.revisions
+		same := f.width == f2.width && f.height == f2.height
+		for y := 0; y < f2.height; y++ {
+			for x := 0; x < f2.width; x++ {
+				if same && f.GetField(x, y) == f2.GetField(x, y) {
+					continue
+				}
+				f2.revision++
+				if f2.revisions == nil {
+					f2.revisions = map[image.Point]int{}
+				}
+				f2.revisions[image.Pt(x, y)] = f2.revision
+			}
+		}
+	}
+	*f = *f2
+	return nil
+}
@@ -0,0 +1,76 @@
+package field
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testStateFile = "version 4\nsize 3 2\n" + testLegend +
+	"warp 1 0 \"\" 0 1\ngroup 2 1 1\nplatform bar 2 0.5 loop 0 1 1 1\n" + testMap
+
+func TestStateRoundTrip(t *testing.T) {
+	f, err := Parse(strings.NewReader(testStateFile), "test.inofield")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f2 Field
+	if err := json.Unmarshal(data, &f2); err != nil {
+		t.Fatal(err)
+	}
+	if w, ok := f2.Warp(1, 0); !ok || w.X != 0 || w.Y != 1 {
+		t.Errorf("warp: got %v, %t", w, ok)
+	}
+	if got := f2.Group(2, 1); got != 1 {
+		t.Errorf("group: got %d, want 1", got)
+	}
+	if got := len(f2.Platforms()); got != 1 {
+		t.Errorf("platforms: got %d, want 1", got)
+	}
+}
+
+func TestStateErrors(t *testing.T) {
+	f, err := Parse(strings.NewReader(testStateFile), "test.inofield")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		modify func(j *fieldJSON)
+		msg    string
+	}{
+		{"warp", func(j *fieldJSON) { j.Warps[0].X = 3 }, "warp (3, 0) is out of the field"},
+		{"group", func(j *fieldJSON) { j.Groups[0].Y = -1 }, "group (2, -1) is out of the field"},
+		{"platform/type", func(j *fieldJSON) { j.Platforms[0].Type = "none" }, "platform type none is not ridable"},
+		{"platform/width", func(j *fieldJSON) { j.Platforms[0].Width = 0 }, "invalid platform width 0"},
+		{"platform/speed", func(j *fieldJSON) { j.Platforms[0].Speed = 0 }, "invalid platform speed 0"},
+		{"platform/point", func(j *fieldJSON) { j.Platforms[0].Path[1] = [2]int{1, 2} }, "platform point (1, 2) is out of the field"},
+		{"platform/end", func(j *fieldJSON) { j.Platforms[0].Path[1] = [2]int{2, 1} }, "platform point (2, 1) is out of the field"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var j fieldJSON
+			if err := json.Unmarshal(data, &j); err != nil {
+				t.Fatal(err)
+			}
+			c.modify(&j)
+			data, err := json.Marshal(j)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var f2 Field
+			if err := json.Unmarshal(data, &f2); err == nil || !strings.Contains(err.Error(), c.msg) {
+				t.Errorf("got %v, want an error with %q", err, c.msg)
+			}
+		})
+	}
+}
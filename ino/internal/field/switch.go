@@ -0,0 +1,124 @@
+package field
+
+import (
+	"image"
+	"sort"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+const (
+	DOOR_OPEN_TIME = 12 // 扉が開き切るまで
+)
+
+// Switches and doors are linked by their group ids. Touching a switch toggles its group,
+// and the doors in an active group open. The tiles without group ids are in the group 0.
+
+func isGroupTile(t fieldtype.FieldType) bool {
+	return t == fieldtype.FIELD_SWITCH || t == fieldtype.FIELD_DOOR
+}
+
+// Group returns the group id of the switch or the door at (x, y).
+func (f *Field) Group(x, y int) int {
+	return f.groups[image.Pt(x, y)]
+}
+
+// SetGroup sets the group id of the switch or the door at (x, y).
+func (f *Field) SetGroup(x, y int, id int) {
+	if !isGroupTile(f.GetField(x, y)) {
+		return
+	}
+	if id == 0 {
+		delete(f.groups, image.Pt(x, y))
+		return
+	}
+	if f.groups == nil {
+		f.groups = map[image.Point]int{}
+	}
+	f.groups[image.Pt(x, y)] = id
+}
+
+// Groups returns the positions of the switches and the doors that have non-zero group ids,
+// in the reading order.
+func (f *Field) Groups() []image.Point {
+	var ps []image.Point
+	for p := range f.groups {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Y != ps[j].Y {
+			return ps[i].Y < ps[j].Y
+		}
+		return ps[i].X < ps[j].X
+	})
+	return ps
+}
+
+// ToggleSwitch toggles the group of the switch at (x, y).
+func (f *Field) ToggleSwitch(x, y int) {
+	if f.GetField(x, y) != fieldtype.FIELD_SWITCH {
+		return
+	}
+	if f.switches == nil {
+		f.switches = map[int]bool{}
+	}
+	g := f.Group(x, y)
+	f.switches[g] = !f.switches[g]
+}
+
+// IsSwitchOn reports whether the group id is active.
+func (f *Field) IsSwitchOn(id int) bool {
+	return f.switches[id]
+}
+
+// doorOpen reports whether the door at (x, y) is open enough to pass.
+func (f *Field) doorOpen(x, y int) bool {
+	return f.doors[f.Group(x, y)] >= DOOR_OPEN_TIME
+}
+
+func (f *Field) updateDoors() {
+	for g, on := range f.switches {
+		t := f.doors[g]
+		switch {
+		case on && t < DOOR_OPEN_TIME:
+			t++
+		case !on && t > 0:
+			// 誰かがいる扉は閉まらない
+			if t == DOOR_OPEN_TIME && f.doorOccupied(g) {
+				continue
+			}
+			t--
+		}
+		if f.doors == nil {
+			f.doors = map[int]int{}
+		}
+		f.doors[g] = t
+	}
+}
+
+func (f *Field) doorOccupied(id int) bool {
+	for p := range f.occupied {
+		if f.GetField(p.X, p.Y) == fieldtype.FIELD_DOOR && f.Group(p.X, p.Y) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// groupImagePosition returns the upper-left position of the switch or the door at (x, y)
+// in the "ino" image.
+//
+// The frames of a switch are off and on, and the frames of a door are from closed to open,
+// in the column of the tile.
+func (f *Field) groupImagePosition(x, y int) (sx, sy int) {
+	t := f.GetField(x, y)
+	sx, sy = TypeImagePosition(t, 0)
+	g := f.Group(x, y)
+	if t == fieldtype.FIELD_SWITCH {
+		if f.switches[g] {
+			sy += CHAR_SIZE
+		}
+		return sx, sy
+	}
+	return sx, sy + f.doors[g]*3/DOOR_OPEN_TIME*CHAR_SIZE
+}
@@ -0,0 +1,828 @@
+package field
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+// Tiled (https://www.mapeditor.org/) maps are supported in both the XML (.tmx) and the JSON (.tmj) formats.
+//
+// A Tiled map must be orthogonal and finite. The field is read from the tile layer named "field",
+// or from the only tile layer if there is no such layer. Each tile used in the layer must have a
+// string property TiledTypeProperty whose value is a field type name like "block" or "item_life".
+// Empty tiles are none. The map's string property "name" is the name of the field.
+// External tilesets (.tsx and .tsj) are read relative to the map file.
+//
+// The destinations of warp tiles are objects in the object layer named "warps". Each object is
+// placed on a warp tile and has the properties "map" (string), "x" and "y" (int). See Warp.
+//
+// Moving platforms and the group ids of switches and doors are not supported yet. Use the level
+// file format for fields with them.
+
+// TiledTypeProperty is the name of the tile property that maps a Tiled tile to a field type.
+const TiledTypeProperty = "fieldtype"
+
+const (
+	tiledLayerName     = "field"
+	tiledWarpLayerName = "warps"
+	tiledTileCount     = 256
+	tiledColumns       = 16
+
+	tiledFlipFlags = 0xf0000000
+)
+
+type tiledTileset struct {
+	firstGID int
+	types    map[int]fieldtype.FieldType
+}
+
+type tiledLayer struct {
+	name   string
+	width  int
+	height int
+	gids   []uint32
+}
+
+type tiledWarp struct {
+	id    int
+	x     float64
+	y     float64
+	props map[string]string
+}
+
+type tiledMap struct {
+	filename string
+	width    int
+	height   int
+	name     string
+	tilesets []*tiledTileset
+	layers   []*tiledLayer
+	warps    []tiledWarp
+}
+
+func (m *tiledMap) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", m.filename, fmt.Sprintf(format, args...))
+}
+
+func (m *tiledMap) fieldType(gid uint32) (fieldtype.FieldType, bool) {
+	gid &^= tiledFlipFlags
+	if gid == 0 {
+		return fieldtype.FIELD_NONE, true
+	}
+	var ts *tiledTileset
+	for _, t := range m.tilesets {
+		if t.firstGID <= int(gid) && (ts == nil || ts.firstGID < t.firstGID) {
+			ts = t
+		}
+	}
+	if ts == nil {
+		return 0, false
+	}
+	t, ok := ts.types[int(gid)-ts.firstGID]
+	return t, ok
+}
+
+func (m *tiledMap) toField() (*Field, error) {
+	var layer *tiledLayer
+	for _, l := range m.layers {
+		if l.name == tiledLayerName {
+			layer = l
+			break
+		}
+	}
+	if layer == nil {
+		if len(m.layers) != 1 {
+			return nil, m.errorf("%d tile layers found; name the field layer %q", len(m.layers), tiledLayerName)
+		}
+		layer = m.layers[0]
+	}
+	if layer.width != m.width || layer.height != m.height {
+		return nil, m.errorf("layer %q size %dx%d doesn't match the map size %dx%d", layer.name, layer.width, layer.height, m.width, m.height)
+	}
+	if m.width <= 0 || m.height <= 0 {
+		return nil, m.errorf("invalid size %dx%d", m.width, m.height)
+	}
+	if m.width > MaxSize || m.height > MaxSize {
+		return nil, m.errorf("too large size %dx%d (max %dx%d)", m.width, m.height, MaxSize, MaxSize)
+	}
+	if len(layer.gids) != m.width*m.height {
+		return nil, m.errorf("layer %q has %d tiles but the size is %dx%d", layer.name, len(layer.gids), m.width, m.height)
+	}
+
+	f := New(m.width, m.height)
+	f.name = m.name
+	for i, gid := range layer.gids {
+		t, ok := m.fieldType(gid)
+		if !ok {
+			return nil, m.errorf("tile at (%d, %d) has no %q property", i%m.width, i/m.width, TiledTypeProperty)
+		}
+		f.field[i] = t
+	}
+
+	for _, w := range m.warps {
+		x := int(w.x) / CHAR_SIZE
+		y := int(w.y) / CHAR_SIZE
+		if f.GetField(x, y) != fieldtype.FIELD_WARP {
+			return nil, m.errorf("warp object %d: no warp tile at (%d, %d)", w.id, x, y)
+		}
+		tx, err1 := strconv.Atoi(w.props["x"])
+		ty, err2 := strconv.Atoi(w.props["y"])
+		if err1 != nil || err2 != nil {
+			return nil, m.errorf("warp object %d: invalid destination (%q, %q)", w.id, w.props["x"], w.props["y"])
+		}
+		f.SetWarp(x, y, Warp{
+			Map: w.props["map"],
+			X:   tx,
+			Y:   ty,
+		})
+	}
+	return f, nil
+}
+
+func parseTiledTypes(filename string, tiles map[int]string) (map[int]fieldtype.FieldType, error) {
+	types := map[int]fieldtype.FieldType{}
+	for id, name := range tiles {
+		t, ok := fieldtype.Parse(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: tile %d: unknown field type %q", filename, id, name)
+		}
+		types[id] = t
+	}
+	return types, nil
+}
+
+func decodeTiledData(encoding, compression, data string) ([]uint32, error) {
+	switch encoding {
+	case "csv":
+		var gids []uint32
+		for _, s := range strings.Split(data, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			gid, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			gids = append(gids, uint32(gid))
+		}
+		return gids, nil
+	case "base64":
+		bs, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return nil, err
+		}
+		var r io.Reader = bytes.NewReader(bs)
+		switch compression {
+		case "":
+		case "zlib":
+			zr, err := zlib.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+			r = zr
+		case "gzip":
+			gr, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			defer gr.Close()
+			r = gr
+		default:
+			return nil, fmt.Errorf("unsupported compression %q", compression)
+		}
+		bs, err = io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(bs)%4 != 0 {
+			return nil, fmt.Errorf("invalid data length %d", len(bs))
+		}
+		gids := make([]uint32, len(bs)/4)
+		for i := range gids {
+			gids[i] = binary.LittleEndian.Uint32(bs[4*i:])
+		}
+		return gids, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", encoding)
+}
+
+// tiledTypeID returns the tile ID in the atlas for t at the first frame.
+func tiledTypeID(t fieldtype.FieldType) int {
+	sx, sy := TypeImagePosition(t, 0)
+	return sy/CHAR_SIZE*tiledColumns + sx/CHAR_SIZE
+}
+
+// tiledGID returns the global tile ID of the tile at index i for the exporters.
+// The exporters use one tileset whose first GID is 1, and FIELD_NONE is exported as an empty tile.
+func (f *Field) tiledGID(i int) uint32 {
+	if f.field[i] == fieldtype.FIELD_NONE {
+		return 0
+	}
+	return uint32(tiledTypeID(f.field[i]) + 1)
+}
+
+// TMX
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:"value,attr"`
+}
+
+type tmxProperties struct {
+	Properties []tmxProperty `xml:"property"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type tmxTile struct {
+	ID         int            `xml:"id,attr"`
+	Properties *tmxProperties `xml:"properties"`
+}
+
+type tmxTileset struct {
+	XMLName    xml.Name  `xml:"tileset"`
+	FirstGID   int       `xml:"firstgid,attr,omitempty"`
+	Source     string    `xml:"source,attr,omitempty"`
+	Name       string    `xml:"name,attr,omitempty"`
+	TileWidth  int       `xml:"tilewidth,attr,omitempty"`
+	TileHeight int       `xml:"tileheight,attr,omitempty"`
+	TileCount  int       `xml:"tilecount,attr,omitempty"`
+	Columns    int       `xml:"columns,attr,omitempty"`
+	Image      *tmxImage `xml:"image"`
+	Tiles      []tmxTile `xml:"tile"`
+}
+
+type tmxDataTile struct {
+	GID uint32 `xml:"gid,attr"`
+}
+
+type tmxData struct {
+	Encoding    string        `xml:"encoding,attr,omitempty"`
+	Compression string        `xml:"compression,attr,omitempty"`
+	Tiles       []tmxDataTile `xml:"tile"`
+	Data        string        `xml:",chardata"`
+}
+
+type tmxLayer struct {
+	ID     int     `xml:"id,attr"`
+	Name   string  `xml:"name,attr"`
+	Width  int     `xml:"width,attr"`
+	Height int     `xml:"height,attr"`
+	Data   tmxData `xml:"data"`
+}
+
+type tmxObject struct {
+	ID         int            `xml:"id,attr"`
+	X          float64        `xml:"x,attr"`
+	Y          float64        `xml:"y,attr"`
+	Width      float64        `xml:"width,attr,omitempty"`
+	Height     float64        `xml:"height,attr,omitempty"`
+	Properties *tmxProperties `xml:"properties"`
+}
+
+type tmxObjectGroup struct {
+	ID      int         `xml:"id,attr"`
+	Name    string      `xml:"name,attr"`
+	Objects []tmxObject `xml:"object"`
+}
+
+type tmxMap struct {
+	XMLName      xml.Name         `xml:"map"`
+	Version      string           `xml:"version,attr"`
+	Orientation  string           `xml:"orientation,attr"`
+	RenderOrder  string           `xml:"renderorder,attr"`
+	Width        int              `xml:"width,attr"`
+	Height       int              `xml:"height,attr"`
+	TileWidth    int              `xml:"tilewidth,attr"`
+	TileHeight   int              `xml:"tileheight,attr"`
+	Infinite     int              `xml:"infinite,attr"`
+	NextLayerID  int              `xml:"nextlayerid,attr"`
+	NextObjectID int              `xml:"nextobjectid,attr"`
+	Properties   *tmxProperties   `xml:"properties"`
+	Tilesets     []tmxTileset     `xml:"tileset"`
+	Layers       []tmxLayer       `xml:"layer"`
+	ObjectGroups []tmxObjectGroup `xml:"objectgroup"`
+}
+
+func tmxTileTypes(filename string, tiles []tmxTile) (map[int]fieldtype.FieldType, error) {
+	names := map[int]string{}
+	for _, t := range tiles {
+		if t.Properties == nil {
+			continue
+		}
+		for _, p := range t.Properties.Properties {
+			if p.Name == TiledTypeProperty {
+				names[t.ID] = p.Value
+			}
+		}
+	}
+	return parseTiledTypes(filename, names)
+}
+
+func parseTMX(r io.Reader, filename string, open func(name string) (io.ReadCloser, error)) (*Field, error) {
+	var tm tmxMap
+	if err := xml.NewDecoder(r).Decode(&tm); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	m := &tiledMap{
+		filename: filename,
+		width:    tm.Width,
+		height:   tm.Height,
+	}
+	if tm.Orientation != "orthogonal" {
+		return nil, m.errorf("unsupported orientation %q", tm.Orientation)
+	}
+	if tm.Infinite != 0 {
+		return nil, m.errorf("infinite maps are not supported")
+	}
+	if tm.Properties != nil {
+		for _, p := range tm.Properties.Properties {
+			if p.Name == "name" {
+				m.name = p.Value
+			}
+		}
+	}
+
+	for _, t := range tm.Tilesets {
+		tiles := t.Tiles
+		tsname := filename
+		if t.Source != "" {
+			tsname = t.Source
+			f, err := open(t.Source)
+			if err != nil {
+				return nil, err
+			}
+			var ext tmxTileset
+			err = xml.NewDecoder(f).Decode(&ext)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", t.Source, err)
+			}
+			tiles = ext.Tiles
+		}
+		types, err := tmxTileTypes(tsname, tiles)
+		if err != nil {
+			return nil, err
+		}
+		m.tilesets = append(m.tilesets, &tiledTileset{
+			firstGID: t.FirstGID,
+			types:    types,
+		})
+	}
+
+	for _, l := range tm.Layers {
+		layer := &tiledLayer{
+			name:   l.Name,
+			width:  l.Width,
+			height: l.Height,
+		}
+		if l.Data.Encoding == "" {
+			for _, t := range l.Data.Tiles {
+				layer.gids = append(layer.gids, t.GID)
+			}
+		} else {
+			gids, err := decodeTiledData(l.Data.Encoding, l.Data.Compression, l.Data.Data)
+			if err != nil {
+				return nil, m.errorf("layer %q: %v", l.Name, err)
+			}
+			layer.gids = gids
+		}
+		m.layers = append(m.layers, layer)
+	}
+
+	for _, g := range tm.ObjectGroups {
+		if g.Name != tiledWarpLayerName {
+			continue
+		}
+		for _, o := range g.Objects {
+			w := tiledWarp{
+				id:    o.ID,
+				x:     o.X,
+				y:     o.Y,
+				props: map[string]string{},
+			}
+			if o.Properties != nil {
+				for _, p := range o.Properties.Properties {
+					w.props[p.Name] = p.Value
+				}
+			}
+			m.warps = append(m.warps, w)
+		}
+	}
+
+	return m.toField()
+}
+
+// WriteTMX writes f as a Tiled map in the XML format.
+// image is the path of the atlas image (ino.png) relative to the written file.
+func WriteTMX(w io.Writer, f *Field, image string) error {
+	if len(f.platforms) > 0 {
+		return fmt.Errorf("field: moving platforms can't be written in a Tiled map")
+	}
+	if len(f.groups) > 0 {
+		return fmt.Errorf("field: group ids can't be written in a Tiled map")
+	}
+	tm := tmxMap{
+		Version:      "1.10",
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        f.width,
+		Height:       f.height,
+		TileWidth:    CHAR_SIZE,
+		TileHeight:   CHAR_SIZE,
+		NextLayerID:  2,
+		NextObjectID: 1,
+		Properties: &tmxProperties{
+			Properties: []tmxProperty{
+				{Name: "name", Value: f.name},
+			},
+		},
+	}
+
+	ts := tmxTileset{
+		FirstGID:   1,
+		Name:       "ino",
+		TileWidth:  CHAR_SIZE,
+		TileHeight: CHAR_SIZE,
+		TileCount:  tiledTileCount,
+		Columns:    tiledColumns,
+		Image: &tmxImage{
+			Source: image,
+			Width:  tiledColumns * CHAR_SIZE,
+			Height: tiledTileCount / tiledColumns * CHAR_SIZE,
+		},
+	}
+	for _, t := range fieldtype.All() {
+		ts.Tiles = append(ts.Tiles, tmxTile{
+			ID: tiledTypeID(t),
+			Properties: &tmxProperties{
+				Properties: []tmxProperty{
+					{Name: TiledTypeProperty, Value: t.String()},
+				},
+			},
+		})
+	}
+	tm.Tilesets = []tmxTileset{ts}
+
+	var data strings.Builder
+	data.WriteString("\n")
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			data.WriteString(strconv.Itoa(int(f.tiledGID(y*f.width + x))))
+			if x < f.width-1 || y < f.height-1 {
+				data.WriteString(",")
+			}
+		}
+		data.WriteString("\n")
+	}
+	tm.Layers = []tmxLayer{
+		{
+			ID:     1,
+			Name:   tiledLayerName,
+			Width:  f.width,
+			Height: f.height,
+			Data: tmxData{
+				Encoding: "csv",
+				Data:     data.String(),
+			},
+		},
+	}
+
+	if warps := f.Warps(); len(warps) > 0 {
+		g := tmxObjectGroup{
+			ID:   2,
+			Name: tiledWarpLayerName,
+		}
+		for i, p := range warps {
+			wp, _ := f.Warp(p.X, p.Y)
+			g.Objects = append(g.Objects, tmxObject{
+				ID:     i + 1,
+				X:      float64(p.X * CHAR_SIZE),
+				Y:      float64(p.Y * CHAR_SIZE),
+				Width:  CHAR_SIZE,
+				Height: CHAR_SIZE,
+				Properties: &tmxProperties{
+					Properties: []tmxProperty{
+						{Name: "map", Value: wp.Map},
+						{Name: "x", Type: "int", Value: strconv.Itoa(wp.X)},
+						{Name: "y", Type: "int", Value: strconv.Itoa(wp.Y)},
+					},
+				},
+			})
+		}
+		tm.ObjectGroups = []tmxObjectGroup{g}
+		tm.NextLayerID = 3
+		tm.NextObjectID = len(warps) + 1
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", " ")
+	if err := e.Encode(tm); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// TMJ
+
+type tmjProperty struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type tmjTile struct {
+	ID         int           `json:"id"`
+	Properties []tmjProperty `json:"properties,omitempty"`
+}
+
+type tmjTileset struct {
+	FirstGID    int       `json:"firstgid,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	ImageWidth  int       `json:"imagewidth,omitempty"`
+	ImageHeight int       `json:"imageheight,omitempty"`
+	TileWidth   int       `json:"tilewidth,omitempty"`
+	TileHeight  int       `json:"tileheight,omitempty"`
+	TileCount   int       `json:"tilecount,omitempty"`
+	Columns     int       `json:"columns,omitempty"`
+	Tiles       []tmjTile `json:"tiles,omitempty"`
+}
+
+type tmjObject struct {
+	ID         int           `json:"id"`
+	X          float64       `json:"x"`
+	Y          float64       `json:"y"`
+	Width      float64       `json:"width"`
+	Height     float64       `json:"height"`
+	Properties []tmjProperty `json:"properties,omitempty"`
+}
+
+type tmjLayer struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	X           int             `json:"x"`
+	Y           int             `json:"y"`
+	Opacity     float64         `json:"opacity"`
+	Visible     bool            `json:"visible"`
+	Encoding    string          `json:"encoding,omitempty"`
+	Compression string          `json:"compression,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	Objects     []tmjObject     `json:"objects,omitempty"`
+}
+
+type tmjMap struct {
+	Type         string        `json:"type"`
+	Version      string        `json:"version"`
+	Orientation  string        `json:"orientation"`
+	RenderOrder  string        `json:"renderorder"`
+	Width        int           `json:"width"`
+	Height       int           `json:"height"`
+	TileWidth    int           `json:"tilewidth"`
+	TileHeight   int           `json:"tileheight"`
+	Infinite     bool          `json:"infinite"`
+	NextLayerID  int           `json:"nextlayerid"`
+	NextObjectID int           `json:"nextobjectid"`
+	Properties   []tmjProperty `json:"properties,omitempty"`
+	Tilesets     []tmjTileset  `json:"tilesets"`
+	Layers       []tmjLayer    `json:"layers"`
+}
+
+func tmjTileTypes(filename string, tiles []tmjTile) (map[int]fieldtype.FieldType, error) {
+	names := map[int]string{}
+	for _, t := range tiles {
+		for _, p := range t.Properties {
+			if p.Name != TiledTypeProperty {
+				continue
+			}
+			s, ok := p.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: tile %d: %q must be a string", filename, t.ID, TiledTypeProperty)
+			}
+			names[t.ID] = s
+		}
+	}
+	return parseTiledTypes(filename, names)
+}
+
+func parseTMJ(r io.Reader, filename string, open func(name string) (io.ReadCloser, error)) (*Field, error) {
+	var tm tmjMap
+	if err := json.NewDecoder(r).Decode(&tm); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	m := &tiledMap{
+		filename: filename,
+		width:    tm.Width,
+		height:   tm.Height,
+	}
+	if tm.Orientation != "orthogonal" {
+		return nil, m.errorf("unsupported orientation %q", tm.Orientation)
+	}
+	if tm.Infinite {
+		return nil, m.errorf("infinite maps are not supported")
+	}
+	for _, p := range tm.Properties {
+		if s, ok := p.Value.(string); ok && p.Name == "name" {
+			m.name = s
+		}
+	}
+
+	for _, t := range tm.Tilesets {
+		tiles := t.Tiles
+		tsname := filename
+		if t.Source != "" {
+			tsname = t.Source
+			f, err := open(t.Source)
+			if err != nil {
+				return nil, err
+			}
+			var ext tmjTileset
+			err = json.NewDecoder(f).Decode(&ext)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", t.Source, err)
+			}
+			tiles = ext.Tiles
+		}
+		types, err := tmjTileTypes(tsname, tiles)
+		if err != nil {
+			return nil, err
+		}
+		m.tilesets = append(m.tilesets, &tiledTileset{
+			firstGID: t.FirstGID,
+			types:    types,
+		})
+	}
+
+	for _, l := range tm.Layers {
+		if l.Type == "objectgroup" && l.Name == tiledWarpLayerName {
+			for _, o := range l.Objects {
+				w := tiledWarp{
+					id:    o.ID,
+					x:     o.X,
+					y:     o.Y,
+					props: map[string]string{},
+				}
+				for _, p := range o.Properties {
+					w.props[p.Name] = fmt.Sprint(p.Value)
+				}
+				m.warps = append(m.warps, w)
+			}
+			continue
+		}
+		if l.Type != "tilelayer" {
+			continue
+		}
+		layer := &tiledLayer{
+			name:   l.Name,
+			width:  l.Width,
+			height: l.Height,
+		}
+		if l.Encoding == "" || l.Encoding == "csv" {
+			if err := json.Unmarshal(l.Data, &layer.gids); err != nil {
+				return nil, m.errorf("layer %q: %v", l.Name, err)
+			}
+		} else {
+			var data string
+			if err := json.Unmarshal(l.Data, &data); err != nil {
+				return nil, m.errorf("layer %q: %v", l.Name, err)
+			}
+			gids, err := decodeTiledData(l.Encoding, l.Compression, data)
+			if err != nil {
+				return nil, m.errorf("layer %q: %v", l.Name, err)
+			}
+			layer.gids = gids
+		}
+		m.layers = append(m.layers, layer)
+	}
+
+	return m.toField()
+}
+
+// WriteTMJ writes f as a Tiled map in the JSON format.
+// image is the path of the atlas image (ino.png) relative to the written file.
+func WriteTMJ(w io.Writer, f *Field, image string) error {
+	if len(f.platforms) > 0 {
+		return fmt.Errorf("field: moving platforms can't be written in a Tiled map")
+	}
+	if len(f.groups) > 0 {
+		return fmt.Errorf("field: group ids can't be written in a Tiled map")
+	}
+	tm := tmjMap{
+		Type:         "map",
+		Version:      "1.10",
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        f.width,
+		Height:       f.height,
+		TileWidth:    CHAR_SIZE,
+		TileHeight:   CHAR_SIZE,
+		NextLayerID:  2,
+		NextObjectID: 1,
+		Properties: []tmjProperty{
+			{Name: "name", Type: "string", Value: f.name},
+		},
+	}
+
+	ts := tmjTileset{
+		FirstGID:    1,
+		Name:        "ino",
+		Image:       image,
+		ImageWidth:  tiledColumns * CHAR_SIZE,
+		ImageHeight: tiledTileCount / tiledColumns * CHAR_SIZE,
+		TileWidth:   CHAR_SIZE,
+		TileHeight:  CHAR_SIZE,
+		TileCount:   tiledTileCount,
+		Columns:     tiledColumns,
+	}
+	for _, t := range fieldtype.All() {
+		ts.Tiles = append(ts.Tiles, tmjTile{
+			ID: tiledTypeID(t),
+			Properties: []tmjProperty{
+				{Name: TiledTypeProperty, Type: "string", Value: t.String()},
+			},
+		})
+	}
+	tm.Tilesets = []tmjTileset{ts}
+
+	gids := make([]uint32, len(f.field))
+	for i := range gids {
+		gids[i] = f.tiledGID(i)
+	}
+	data, err := json.Marshal(gids)
+	if err != nil {
+		return err
+	}
+	tm.Layers = []tmjLayer{
+		{
+			ID:      1,
+			Name:    tiledLayerName,
+			Type:    "tilelayer",
+			Width:   f.width,
+			Height:  f.height,
+			Opacity: 1,
+			Visible: true,
+			Data:    data,
+		},
+	}
+
+	if warps := f.Warps(); len(warps) > 0 {
+		l := tmjLayer{
+			ID:      2,
+			Name:    tiledWarpLayerName,
+			Type:    "objectgroup",
+			Opacity: 1,
+			Visible: true,
+		}
+		for i, p := range warps {
+			wp, _ := f.Warp(p.X, p.Y)
+			l.Objects = append(l.Objects, tmjObject{
+				ID:     i + 1,
+				X:      float64(p.X * CHAR_SIZE),
+				Y:      float64(p.Y * CHAR_SIZE),
+				Width:  CHAR_SIZE,
+				Height: CHAR_SIZE,
+				Properties: []tmjProperty{
+					{Name: "map", Type: "string", Value: wp.Map},
+					{Name: "x", Type: "int", Value: wp.X},
+					{Name: "y", Type: "int", Value: wp.Y},
+				},
+			})
+		}
+		tm.Layers = append(tm.Layers, l)
+		tm.NextLayerID = 3
+		tm.NextObjectID = len(warps) + 1
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", " ")
+	return e.Encode(tm)
+}
@@ -0,0 +1,277 @@
+package field
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+func mustParseType(t *testing.T, name string) fieldtype.FieldType {
+	t.Helper()
+	ft, ok := fieldtype.Parse(name)
+	if !ok {
+		t.Fatalf("unknown field type %q", name)
+	}
+	return ft
+}
+
+// testTiledField returns a small field with a warp for the round trips.
+func testTiledField(t *testing.T) *Field {
+	f := New(5, 4)
+	f.name = "TILED TEST"
+	for x := 0; x < 5; x++ {
+		f.field[3*5+x] = mustParseType(t, "block")
+	}
+	f.field[2*5+1] = mustParseType(t, "item_life")
+	f.field[2*5+2] = mustParseType(t, "item_startpoint")
+	f.field[1*5+3] = mustParseType(t, "spike")
+	f.field[2*5+4] = fieldtype.FIELD_WARP
+	f.SetWarp(4, 2, Warp{Map: "room2.inofield", X: 3, Y: 7})
+	return f
+}
+
+func assertSameField(t *testing.T, got, want *Field) {
+	t.Helper()
+	if got.name != want.name {
+		t.Errorf("name: got %q, want %q", got.name, want.name)
+	}
+	if got.width != want.width || got.height != want.height {
+		t.Fatalf("size: got %dx%d, want %dx%d", got.width, got.height, want.width, want.height)
+	}
+	if !reflect.DeepEqual(got.field, want.field) {
+		t.Errorf("tiles: got %v, want %v", got.field, want.field)
+	}
+	if !reflect.DeepEqual(got.warps, want.warps) {
+		t.Errorf("warps: got %v, want %v", got.warps, want.warps)
+	}
+}
+
+func TestTiledRoundTrip(t *testing.T) {
+	cases := []struct {
+		name  string
+		write func(w io.Writer, f *Field, image string) error
+	}{
+		{"a.tmx", WriteTMX},
+		{"a.tmj", WriteTMJ},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := testTiledField(t)
+			var buf bytes.Buffer
+			if err := c.write(&buf, f, "ino.png"); err != nil {
+				t.Fatal(err)
+			}
+			fsys := fstest.MapFS{
+				c.name: {Data: buf.Bytes()},
+			}
+			got, err := Load(fsys, c.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameField(t, got, f)
+		})
+	}
+}
+
+// encodeTiledData encodes gids as the data of a layer.
+func encodeTiledData(t *testing.T, gids []uint32, encoding, compression string) string {
+	if encoding == "csv" {
+		var strs []string
+		for _, gid := range gids {
+			strs = append(strs, fmt.Sprint(gid))
+		}
+		return strings.Join(strs, ",")
+	}
+
+	var raw bytes.Buffer
+	for _, gid := range gids {
+		binary.Write(&raw, binary.LittleEndian, gid)
+	}
+	var buf bytes.Buffer
+	switch compression {
+	case "":
+		buf = raw
+	case "zlib":
+		w := zlib.NewWriter(&buf)
+		w.Write(raw.Bytes())
+		w.Close()
+	case "gzip":
+		w := gzip.NewWriter(&buf)
+		w.Write(raw.Bytes())
+		w.Close()
+	default:
+		t.Fatalf("unknown compression %q", compression)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// The tileset of the hand-written maps: the tile 0 is block and the tile 1 is item_life.
+const (
+	testTSX = `<?xml version="1.0" encoding="UTF-8"?>
+<tileset name="test" tilewidth="16" tileheight="16" tilecount="2" columns="2">
+ <tile id="0"><properties><property name="fieldtype" value="block"/></properties></tile>
+ <tile id="1"><properties><property name="fieldtype" value="item_life"/></properties></tile>
+</tileset>
+`
+	testTSJ = `{"name": "test", "tilewidth": 16, "tileheight": 16, "tilecount": 2, "columns": 2, "tiles": [
+ {"id": 0, "properties": [{"name": "fieldtype", "type": "string", "value": "block"}]},
+ {"id": 1, "properties": [{"name": "fieldtype", "type": "string", "value": "item_life"}]}
+]}
+`
+)
+
+func testTMX(tileset, encoding, compression, data string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="3" height="2" tilewidth="16" tileheight="16" infinite="0">
+ ` + tileset + `
+ <layer id="1" name="field" width="3" height="2">
+  <data encoding="` + encoding + `" compression="` + compression + `">` + data + `</data>
+ </layer>
+</map>
+`
+}
+
+func testTMJ(tileset, encoding, compression, data string) string {
+	return `{"type": "map", "orientation": "orthogonal", "width": 3, "height": 2, "tilewidth": 16, "tileheight": 16, "infinite": false,
+ "tilesets": [` + tileset + `],
+ "layers": [{"id": 1, "name": "field", "type": "tilelayer", "width": 3, "height": 2,
+  "encoding": "` + encoding + `", "compression": "` + compression + `", "data": ` + data + `}]}
+`
+}
+
+var (
+	testTiledGIDs  = []uint32{1, 0, 2, 0, 1, 1}
+	testTiledTypes = []string{"block", "none", "item_life", "none", "block", "block"}
+)
+
+func TestTiledEncodings(t *testing.T) {
+	inlineTMX := `<tileset firstgid="1" name="test" tilewidth="16" tileheight="16" tilecount="2" columns="2">
+ <tile id="0"><properties><property name="fieldtype" value="block"/></properties></tile>
+ <tile id="1"><properties><property name="fieldtype" value="item_life"/></properties></tile>
+</tileset>`
+	inlineTMJ := `{"firstgid": 1, "name": "test", "tilewidth": 16, "tileheight": 16, "tilecount": 2, "columns": 2, "tiles": [
+ {"id": 0, "properties": [{"name": "fieldtype", "type": "string", "value": "block"}]},
+ {"id": 1, "properties": [{"name": "fieldtype", "type": "string", "value": "item_life"}]}]}`
+
+	cases := []struct {
+		encoding    string
+		compression string
+	}{
+		{"csv", ""},
+		{"base64", ""},
+		{"base64", "zlib"},
+		{"base64", "gzip"},
+	}
+	for _, c := range cases {
+		data := encodeTiledData(t, testTiledGIDs, c.encoding, c.compression)
+		tmjData := `"` + data + `"`
+		if c.encoding == "csv" {
+			tmjData = "[" + data + "]"
+		}
+		files := map[string]string{
+			"a.tmx": testTMX(inlineTMX, c.encoding, c.compression, data),
+			"a.tmj": testTMJ(inlineTMJ, c.encoding, c.compression, tmjData),
+		}
+		for name, content := range files {
+			t.Run(name+"/"+c.encoding+c.compression, func(t *testing.T) {
+				f, err := Load(fstest.MapFS{name: {Data: []byte(content)}}, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i, tname := range testTiledTypes {
+					if got, want := f.field[i], mustParseType(t, tname); got != want {
+						t.Errorf("tile %d: got %v, want %v", i, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestTiledExternalTileset(t *testing.T) {
+	data := encodeTiledData(t, testTiledGIDs, "csv", "")
+	fsys := fstest.MapFS{
+		"maps/a.tmx":             {Data: []byte(testTMX(`<tileset firstgid="1" source="tilesets/test.tsx"/>`, "csv", "", data))},
+		"maps/a.tmj":             {Data: []byte(testTMJ(`{"firstgid": 1, "source": "tilesets/test.tsj"}`, "csv", "", "["+data+"]"))},
+		"maps/tilesets/test.tsx": {Data: []byte(testTSX)},
+		"maps/tilesets/test.tsj": {Data: []byte(testTSJ)},
+	}
+	for _, name := range []string{"maps/a.tmx", "maps/a.tmj"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := Load(fsys, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, tname := range testTiledTypes {
+				if got, want := f.field[i], mustParseType(t, tname); got != want {
+					t.Errorf("tile %d: got %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTiledErrors(t *testing.T) {
+	// タイル 1 には fieldtype がない
+	noTypeTMX := `<tileset firstgid="1" name="test" tilewidth="16" tileheight="16" tilecount="2" columns="2">
+ <tile id="0"><properties><property name="fieldtype" value="block"/></properties></tile>
+</tileset>`
+	noTypeTMJ := `{"firstgid": 1, "name": "test", "tilewidth": 16, "tileheight": 16, "tilecount": 2, "columns": 2, "tiles": [
+ {"id": 0, "properties": [{"name": "fieldtype", "type": "string", "value": "block"}]}]}`
+	data := encodeTiledData(t, testTiledGIDs, "csv", "")
+
+	cases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "a.tmx",
+			content: testTMX(noTypeTMX, "csv", "", data),
+			want:    `tile at (2, 0) has no "fieldtype" property`,
+		},
+		{
+			name:    "a.tmj",
+			content: testTMJ(noTypeTMJ, "csv", "", "["+data+"]"),
+			want:    `tile at (2, 0) has no "fieldtype" property`,
+		},
+		{
+			name:    "a.tmx",
+			content: testTMX(noTypeTMX, "csv", "", "1,1,1"),
+			want:    "has 3 tiles but the size is 3x2",
+		},
+		{
+			name:    "a.tmj",
+			content: testTMJ(noTypeTMJ, "csv", "", "[1,1,1]"),
+			want:    "has 3 tiles but the size is 3x2",
+		},
+		{
+			name:    "a.tmx",
+			content: strings.Replace(testTMX(noTypeTMX, "csv", "", data), `infinite="0"`, `infinite="1"`, 1),
+			want:    "infinite maps are not supported",
+		},
+		{
+			name:    "a.tmj",
+			content: strings.Replace(testTMJ(noTypeTMJ, "csv", "", "["+data+"]"), `"infinite": false`, `"infinite": true`, 1),
+			want:    "infinite maps are not supported",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name+"/"+c.want, func(t *testing.T) {
+			_, err := Load(fstest.MapFS{c.name: {Data: []byte(c.content)}}, c.name)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("got %v, want an error with %q", err, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package field
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// World is a set of fields linked by warps.
+//
+// Each field is identified by the name of its level file. The fields in a world keep their states,
+// like erased items, while the player is in other fields.
+type World struct {
+	start  string
+	fields map[string]*Field
+	join   func(base, rel string) string
+}
+
+// LoadWorld loads the level file name in fsys and all the level files reachable by its warps.
+func LoadWorld(fsys fs.FS, name string) (*World, error) {
+	f, err := Load(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return newWorld(name, f, func(name string) (*Field, error) {
+		return Load(fsys, name)
+	}, func(base, rel string) string {
+		return path.Join(path.Dir(base), rel)
+	})
+}
+
+// LoadWorldFile is like LoadWorld but reads the files from the operating system.
+func LoadWorldFile(name string) (*World, error) {
+	f, err := LoadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewWorldFile(name, f)
+}
+
+// NewWorldFile returns a world that starts with f as the level file name.
+// The other level files reachable by the warps are read from the operating system.
+func NewWorldFile(name string, f *Field) (*World, error) {
+	return newWorld(name, f, LoadFile, func(base, rel string) string {
+		return filepath.Join(filepath.Dir(base), filepath.FromSlash(rel))
+	})
+}
+
+func newWorld(name string, start *Field, load func(name string) (*Field, error), join func(base, rel string) string) (*World, error) {
+	w := &World{
+		start:  name,
+		fields: map[string]*Field{name: start},
+		join:   join,
+	}
+
+	queue := []string{name}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		f := w.fields[name]
+		for _, p := range f.Warps() {
+			wp, _ := f.Warp(p.X, p.Y)
+			dest := w.Dest(name, wp)
+			df, ok := w.fields[dest]
+			if !ok {
+				var err error
+				df, err = load(dest)
+				if err != nil {
+					return nil, err
+				}
+				w.fields[dest] = df
+				queue = append(queue, dest)
+			}
+			if !df.inField(wp.X, wp.Y) {
+				return nil, fmt.Errorf("%s: the destination (%d, %d) of the warp at (%d, %d) is out of %s", name, wp.X, wp.Y, p.X, p.Y, dest)
+			}
+		}
+	}
+	return w, nil
+}
+
+// Clone returns a copy of the world with the current states of its fields.
+func (w *World) Clone() *World {
+	w2 := &World{
+		start:  w.start,
+		fields: map[string]*Field{},
+		join:   w.join,
+	}
+	for name, f := range w.fields {
+		w2.fields[name] = f.Clone()
+	}
+	return w2
+}
+
+// Start returns the field where the game starts and its name.
+func (w *World) Start() (string, *Field) {
+	return w.start, w.fields[w.start]
+}
+
+// Field returns the field named name.
+func (w *World) Field(name string) *Field {
+	return w.fields[name]
+}
+
+// Names returns the names of all the fields in the world.
+func (w *World) Names() []string {
+	var names []string
+	for name := range w.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dest returns the name of the field where the warp wp in the field from leads.
+func (w *World) Dest(from string, wp Warp) string {
+	if wp.Map == "" {
+		return from
+	}
+	return w.join(from, wp.Map)
+}
@@ -1,6 +1,8 @@
 package fieldtype
 
 import (
+	"fmt"
+
 	"golang.org/x/text/language"
 
 	"github.com/hajimehoshi/go-inovation/ino/internal/text"
@@ -18,6 +20,19 @@ const (
 	FIELD_SCROLL_R                      // ベルト床右
 	FIELD_SPIKE                         // トゲ
 	FIELD_SLIP                          // すべる
+	FIELD_WARP                          // ワープ(別のマップへ移動)
+	FIELD_CHECKPOINT                    // チェックポイント(復活地点)
+	FIELD_ENEMY_PATROL                  // 敵(床を往復する)
+	FIELD_ENEMY_BOUNCE                  // 敵(跳ねる)
+	FIELD_ENEMY_DROP                    // 敵(下を通ると落ちてくる)
+	FIELD_CRUMBLE                       // 崩れるブロック
+	FIELD_SWITCH                        // スイッチ(触ると切り替わる)
+	FIELD_DOOR                          // 扉(スイッチが入ると開く)
+	FIELD_WATER                         // 水中
+	FIELD_LOWGRAVITY                    // 低重力
+	FIELD_WIND_L                        // 風(左)
+	FIELD_WIND_R                        // 風(右)
+	FIELD_LADDER                        // はしご
 	FIELD_ITEM_BORDER                   // アイテムチェック用
 	FIELD_ITEM_POWERUP                  // パワーアップ
 	// ふじ系
@@ -48,6 +63,90 @@ const (
 	FIELD_ITEM_MAX
 )
 
+// ClearFlagItems are the items required to clear the game.
+var ClearFlagItems = [...]FieldType{
+	FIELD_ITEM_FUJI,
+	FIELD_ITEM_TAKA,
+	FIELD_ITEM_NASU,
+}
+
 func (f FieldType) ItemMessage(lang language.Tag) string {
 	return text.Get(lang, text.TextID(f-FIELD_ITEM_POWERUP)+text.TextIDItemPowerUp)
 }
+
+var names = map[FieldType]string{
+	FIELD_NONE:            "none",
+	FIELD_HIDEPATH:        "hidepath",
+	FIELD_UNVISIBLE:       "unvisible",
+	FIELD_BLOCK:           "block",
+	FIELD_BAR:             "bar",
+	FIELD_SCROLL_L:        "scroll_l",
+	FIELD_SCROLL_R:        "scroll_r",
+	FIELD_SPIKE:           "spike",
+	FIELD_SLIP:            "slip",
+	FIELD_WARP:            "warp",
+	FIELD_CHECKPOINT:      "checkpoint",
+	FIELD_ENEMY_PATROL:    "enemy_patrol",
+	FIELD_ENEMY_BOUNCE:    "enemy_bounce",
+	FIELD_ENEMY_DROP:      "enemy_drop",
+	FIELD_CRUMBLE:         "crumble",
+	FIELD_SWITCH:          "switch",
+	FIELD_DOOR:            "door",
+	FIELD_WATER:           "water",
+	FIELD_LOWGRAVITY:      "lowgravity",
+	FIELD_WIND_L:          "wind_l",
+	FIELD_WIND_R:          "wind_r",
+	FIELD_LADDER:          "ladder",
+	FIELD_ITEM_POWERUP:    "item_powerup",
+	FIELD_ITEM_FUJI:       "item_fuji",
+	FIELD_ITEM_BUSHI:      "item_bushi",
+	FIELD_ITEM_APPLE:      "item_apple",
+	FIELD_ITEM_V:          "item_v",
+	FIELD_ITEM_TAKA:       "item_taka",
+	FIELD_ITEM_SHUOLDER:   "item_shoulder",
+	FIELD_ITEM_DAGGER:     "item_dagger",
+	FIELD_ITEM_KATAKATA:   "item_katakata",
+	FIELD_ITEM_NASU:       "item_nasu",
+	FIELD_ITEM_BONUS:      "item_bonus",
+	FIELD_ITEM_NURSE:      "item_nurse",
+	FIELD_ITEM_NAZUNA:     "item_nazuna",
+	FIELD_ITEM_GAMEHELL:   "item_gamehell",
+	FIELD_ITEM_GUNDAM:     "item_gundam",
+	FIELD_ITEM_POED:       "item_poed",
+	FIELD_ITEM_MILESTONE:  "item_milestone",
+	FIELD_ITEM_1YEN:       "item_1yen",
+	FIELD_ITEM_TRIANGLE:   "item_triangle",
+	FIELD_ITEM_OMEGA:      "item_omega",
+	FIELD_ITEM_LIFE:       "item_life",
+	FIELD_ITEM_STARTPOINT: "item_startpoint",
+}
+
+// String returns the name used for f in level files.
+func (f FieldType) String() string {
+	if n, ok := names[f]; ok {
+		return n
+	}
+	return fmt.Sprintf("FieldType(%d)", int(f))
+}
+
+// All returns all the field types that can be placed in a field.
+func All() []FieldType {
+	var ts []FieldType
+	for f := FIELD_NONE; f < FIELD_ITEM_MAX; f++ {
+		if f == FIELD_ITEM_BORDER {
+			continue
+		}
+		ts = append(ts, f)
+	}
+	return ts
+}
+
+// Parse returns the FieldType named name in level files.
+func Parse(name string) (FieldType, bool) {
+	for f, n := range names {
+		if n == name {
+			return f, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,144 @@
+package fieldtype
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+)
+
+// Tile is the behavior and the appearance of a field type.
+type Tile struct {
+	// Name is the name of the field type in level files.
+	Name string `json:"name"`
+
+	// Solid reports whether the tile blocks the player from all the directions.
+	Solid bool `json:"solid,omitempty"`
+
+	// Ridable reports whether the player can stand on the tile.
+	// A ridable tile that is not solid is a one-way floor.
+	Ridable bool `json:"ridable,omitempty"`
+
+	// Damage reports whether the tile damages the player.
+	Damage bool `json:"damage,omitempty"`
+
+	// Conveyor is the horizontal speed added to the player on the tile.
+	Conveyor float64 `json:"conveyor,omitempty"`
+
+	// Friction is the ratio of the acceleration on the tile to the one on normal ground.
+	// The default is 1.
+	Friction float64 `json:"friction"`
+
+	// Climbable reports whether the player can climb the tile.
+	// The top of climbable tiles is a one-way floor.
+	Climbable bool `json:"climbable,omitempty"`
+
+	// Item reports whether the tile is collectible.
+	Item bool `json:"item,omitempty"`
+
+	// Hidden reports whether the tile is invisible and uncollectible until the secret is revealed.
+	Hidden bool `json:"hidden,omitempty"`
+
+	// Frames is the number of the animation frames. The frames are placed vertically in the atlas.
+	// The default is 1. Only the tiles with one frame are pre-rendered, so a tile whose frames
+	// look the same should have one frame.
+	Frames int `json:"frames"`
+
+	// Atlas is the position of the first frame in the "ino" image in tiles.
+	Atlas [2]int `json:"atlas"`
+
+	// Zone is the physics parameters for the player in the tile, or nil for the default ones.
+	// A zone tile is drawn over the player.
+	Zone *Zone `json:"zone,omitempty"`
+}
+
+// Zone is a set of the physics parameters. All the parameters must be specified.
+type Zone struct {
+	// Gravity is the vertical acceleration.
+	Gravity float64 `json:"gravity"`
+
+	// FallSpeedMax is the maximum falling speed.
+	FallSpeedMax float64 `json:"fall_speed_max"`
+
+	// AirAccRatio is the ratio of the acceleration in the air.
+	AirAccRatio float64 `json:"air_accratio"`
+
+	// Jump is the vertical speed of a jump.
+	Jump float64 `json:"jump"`
+
+	// Wind is the horizontal speed added to the player.
+	Wind float64 `json:"wind,omitempty"`
+
+	// Swim reports whether the player can jump any times like swimming.
+	Swim bool `json:"swim,omitempty"`
+}
+
+// TILE_ANIMATION_INTERVAL is the number of ticks for one animation frame.
+const TILE_ANIMATION_INTERVAL = 10
+
+var (
+	tiles    = map[FieldType]*Tile{}
+	noneTile = &Tile{Friction: 1, Frames: 1}
+)
+
+func init() {
+	f, err := assets.Assets.Open("tiles.json")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	if err := LoadTiles(f, "tiles.json"); err != nil {
+		panic(err)
+	}
+}
+
+// LoadTiles replaces the tile table with the one in r.
+// The table is a JSON array of Tile, and every field type must have exactly one entry.
+// filename is used only for error messages.
+func LoadTiles(r io.Reader, filename string) error {
+	var entries []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	ts := map[FieldType]*Tile{}
+	for i, e := range entries {
+		t := &Tile{
+			Friction: 1,
+			Frames:   1,
+		}
+		if err := json.Unmarshal(e, t); err != nil {
+			return fmt.Errorf("%s: tile #%d: %w", filename, i, err)
+		}
+		f, ok := Parse(t.Name)
+		if !ok {
+			return fmt.Errorf("%s: tile #%d: unknown field type %q", filename, i, t.Name)
+		}
+		if _, ok := ts[f]; ok {
+			return fmt.Errorf("%s: tile #%d: duplicated field type %q", filename, i, t.Name)
+		}
+		if t.Frames <= 0 {
+			return fmt.Errorf("%s: tile #%d: frames must be positive", filename, i)
+		}
+		if z := t.Zone; z != nil && (z.Gravity <= 0 || z.FallSpeedMax <= 0 || z.AirAccRatio <= 0 || z.Jump >= 0) {
+			return fmt.Errorf("%s: tile #%d: invalid zone parameters", filename, i)
+		}
+		ts[f] = t
+	}
+	for _, f := range All() {
+		if _, ok := ts[f]; !ok {
+			return fmt.Errorf("%s: missing field type %q", filename, f)
+		}
+	}
+	tiles = ts
+	return nil
+}
+
+// Tile returns the properties of f.
+func (f FieldType) Tile() *Tile {
+	if t, ok := tiles[f]; ok {
+		return t
+	}
+	return noneTile
+}
@@ -0,0 +1,62 @@
+package input
+
+// Buttons is the state of the action key and the direction keys in a frame as bits.
+type Buttons uint8
+
+const (
+	ButtonLeft Buttons = 1 << iota
+	ButtonRight
+	ButtonDown
+	ButtonUp
+	ButtonAction
+)
+
+var directionButtons = map[Direction]Buttons{
+	DirectionLeft:  ButtonLeft,
+	DirectionRight: ButtonRight,
+	DirectionDown:  ButtonDown,
+	DirectionUp:    ButtonUp,
+}
+
+// ButtonsOf returns the buttons pressed on c now.
+func ButtonsOf(c Controller) Buttons {
+	var b Buttons
+	for dir, button := range directionButtons {
+		if c.IsDirectionKeyPressed(dir) {
+			b |= button
+		}
+	}
+	if c.IsActionKeyPressed() {
+		b |= ButtonAction
+	}
+	return b
+}
+
+// FrameController is a Controller fed with the buttons frame by frame,
+// like the ones recorded in a replay.
+type FrameController struct {
+	buttons     Buttons
+	prevButtons Buttons
+}
+
+// SetButtons advances the controller by a frame with the buttons b pressed.
+func (f *FrameController) SetButtons(b Buttons) {
+	f.prevButtons = f.buttons
+	f.buttons = b
+}
+
+func (f *FrameController) IsActionKeyPressed() bool {
+	return f.buttons&ButtonAction != 0
+}
+
+func (f *FrameController) IsActionKeyJustPressed() bool {
+	return f.buttons&ButtonAction != 0 && f.prevButtons&ButtonAction == 0
+}
+
+func (f *FrameController) IsDirectionKeyPressed(dir Direction) bool {
+	b, ok := directionButtons[dir]
+	if !ok {
+		panic("not reach")
+	}
+	return f.buttons&b != 0
+}
@@ -26,12 +26,22 @@ const (
 	DirectionLeft Direction = iota
 	DirectionRight
 	DirectionDown
+	DirectionUp
 )
 
+// Controller is the input to drive a player.
+// Current() is the controller of the keyboard, the gamepads and the touches.
+type Controller interface {
+	IsActionKeyPressed() bool
+	IsActionKeyJustPressed() bool
+	IsDirectionKeyPressed(dir Direction) bool
+}
+
 var keys = []ebiten.Key{
 	ebiten.KeyEnter,
 	ebiten.KeySpace,
 	ebiten.KeyLeft,
+	ebiten.KeyUp,
 	ebiten.KeyDown,
 	ebiten.KeyRight,
 
@@ -109,6 +119,8 @@ func (i *Input) Update() {
 				x = 1
 			case ebiten.IsStandardGamepadButtonPressed(i.gamepadID, ebiten.StandardGamepadButtonLeftBottom):
 				y = 1
+			case ebiten.IsStandardGamepadButtonPressed(i.gamepadID, ebiten.StandardGamepadButtonLeftTop):
+				y = -1
 			}
 		} else {
 			x = ebiten.GamepadAxis(i.gamepadID, 0)
@@ -122,7 +134,11 @@ func (i *Input) Update() {
 			i.pressed[ebiten.KeyRight] = struct{}{}
 			gamepadUsed = true
 		}
-		if y >= threshold {
+		switch {
+		case -threshold >= y:
+			i.pressed[ebiten.KeyUp] = struct{}{}
+			gamepadUsed = true
+		case threshold <= y:
 			i.pressed[ebiten.KeyDown] = struct{}{}
 			gamepadUsed = true
 		}
@@ -179,10 +195,31 @@ func inLanguageSwitcher(x, y int) bool {
 	return ScreenWidth*3/4 <= x && y < ScreenHeight/4
 }
 
+func inOptionsSwitcher(x, y int) bool {
+	return x < ScreenWidth/4 && y < ScreenHeight/4
+}
+
+// ゴーストとコンティニューのボタンは、タッチでキーを模倣する下端 64 ピクセルの上に置く
+func inBottomSwitchers(y int) bool {
+	return ScreenHeight-64-ScreenHeight/8 <= y && y < ScreenHeight-64
+}
+
+func inGhostSwitcher(x, y int) bool {
+	return x < ScreenWidth/4 && inBottomSwitchers(y)
+}
+
+func inContinueSwitcher(x, y int) bool {
+	return ScreenWidth*3/4 <= x && inBottomSwitchers(y)
+}
+
+func inSwitcher(x, y int) bool {
+	return inLanguageSwitcher(x, y) || inOptionsSwitcher(x, y) || inGhostSwitcher(x, y) || inContinueSwitcher(x, y)
+}
+
 func (i *Input) IsSpaceTouched() bool {
 	for _, t := range ebiten.TouchIDs() {
 		x, y := ebiten.TouchPosition(t)
-		if !inLanguageSwitcher(x, y) && y < ScreenHeight-64 {
+		if !inSwitcher(x, y) && y < ScreenHeight-64 {
 			return true
 		}
 	}
@@ -192,7 +229,7 @@ func (i *Input) IsSpaceTouched() bool {
 func (i *Input) IsSpaceJustTouched() bool {
 	for _, t := range inpututil.JustPressedTouchIDs() {
 		x, y := ebiten.TouchPosition(t)
-		if !inLanguageSwitcher(x, y) && y < ScreenHeight-64 {
+		if !inSwitcher(x, y) && y < ScreenHeight-64 {
 			return true
 		}
 	}
@@ -229,6 +266,8 @@ func (i *Input) IsDirectionKeyPressed(dir Direction) bool {
 		return i.IsKeyPressed(ebiten.KeyRight)
 	case DirectionDown:
 		return i.IsKeyPressed(ebiten.KeyDown)
+	case DirectionUp:
+		return i.IsKeyPressed(ebiten.KeyUp)
 	default:
 		panic("not reach")
 	}
@@ -250,3 +289,67 @@ func (i *Input) IsLanguageSwitcherPressed() bool {
 	}
 	return false
 }
+
+func (i *Input) IsOptionsSwitcherPressed() bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		return true
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if inOptionsSwitcher(ebiten.CursorPosition()) {
+			return true
+		}
+	}
+	for _, t := range inpututil.JustPressedTouchIDs() {
+		if inOptionsSwitcher(ebiten.TouchPosition(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Input) IsGhostSwitcherPressed() bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeyG) {
+		return true
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if inGhostSwitcher(ebiten.CursorPosition()) {
+			return true
+		}
+	}
+	for _, t := range inpututil.JustPressedTouchIDs() {
+		if inGhostSwitcher(ebiten.TouchPosition(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Input) IsContinueSwitcherPressed() bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		return true
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if inContinueSwitcher(ebiten.CursorPosition()) {
+			return true
+		}
+	}
+	for _, t := range inpututil.JustPressedTouchIDs() {
+		if inContinueSwitcher(ebiten.TouchPosition(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// JustPressedPosition returns the position where the mouse button or a touch is just pressed.
+func (i *Input) JustPressedPosition() (int, int, bool) {
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y := ebiten.CursorPosition()
+		return x, y, true
+	}
+	if ts := inpututil.JustPressedTouchIDs(); len(ts) > 0 {
+		x, y := ebiten.TouchPosition(ts[0])
+		return x, y, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,510 @@
+// Package physics implements the movement rules of the player.
+//
+// The rules are shared by the game and the tools that simulate the game
+// without rendering.
+package physics
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+)
+
+const (
+	PLAYER_SPEED         = 2.0
+	PLAYER_GRD_ACCRATIO  = 0.04
+	PLAYER_AIR_ACCRATIO  = 0.01
+	PLAYER_JUMP          = -4.0
+	PLAYER_GRAVITY       = 0.2
+	PLAYER_FALL_SPEEDMAX = 4.0
+	PLAYER_CLIMB_SPEED   = 1.0
+	PLAYER_JUMPOFF_SPEED = 1.0
+	LIFE_RATIO           = 400
+	MUTEKI_INTERVAL      = 50
+
+	LUNKER_JUMP_DAMAGE1 = 40.0
+	LUNKER_JUMP_DAMAGE2 = 96.0
+)
+
+// DefaultZone is the physics parameters outside of the zone tiles.
+var DefaultZone = fieldtype.Zone{
+	Gravity:      PLAYER_GRAVITY,
+	FallSpeedMax: PLAYER_FALL_SPEEDMAX,
+	AirAccRatio:  PLAYER_AIR_ACCRATIO,
+	Jump:         PLAYER_JUMP,
+}
+
+type PositionF struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Body struct {
+	Position    PositionF `json:"position"`
+	Speed       PositionF `json:"speed"`
+	Direction   int       `json:"direction"`
+	JumpCnt     int       `json:"jumpcnt"`
+	JumpedPoint PositionF `json:"jumpedpoint"`
+}
+
+func NewBody(position PositionF) Body {
+	return Body{
+		Position:    position,
+		JumpedPoint: position,
+	}
+}
+
+// OnWall reports whether the body stands on a ridable tile or a moving platform.
+func (b *Body) OnWall(f *field.Field) bool {
+	return b.onTile(f) || b.onPlatform(f) != nil
+}
+
+func (b *Body) onTile(f *field.Field) bool {
+	if b.ToFieldOfsY() > field.CHAR_SIZE/4 {
+		return false
+	}
+	if f.IsRidable(b.ToFieldX(), b.ToFieldY()+1) && b.ToFieldOfsX() < field.CHAR_SIZE*7/8 {
+		return true
+	}
+	if f.IsRidable(b.ToFieldX()+1, b.ToFieldY()+1) && b.ToFieldOfsX() > field.CHAR_SIZE/8 {
+		return true
+	}
+	return false
+}
+
+// Floor returns the positions of the ridable tiles the body stands on.
+func (b *Body) Floor(f *field.Field) []image.Point {
+	if b.ToFieldOfsY() > field.CHAR_SIZE/4 {
+		return nil
+	}
+	var ps []image.Point
+	x, y := b.ToFieldX(), b.ToFieldY()
+	if f.IsRidable(x, y+1) && b.ToFieldOfsX() < field.CHAR_SIZE*7/8 {
+		ps = append(ps, image.Pt(x, y+1))
+	}
+	if f.IsRidable(x+1, y+1) && b.ToFieldOfsX() > field.CHAR_SIZE/8 {
+		ps = append(ps, image.Pt(x+1, y+1))
+	}
+	return ps
+}
+
+func (b *Body) IsFallable(f *field.Field) bool {
+	if !b.OnWall(f) {
+		return false
+	}
+	if !b.onTile(f) {
+		// 移動床からは降りられる
+		return true
+	}
+	if f.IsWall(b.ToFieldX(), b.ToFieldY()+1) && b.ToFieldOfsX() < field.CHAR_SIZE*7/8 {
+		return false
+	}
+	if f.IsWall(b.ToFieldX()+1, b.ToFieldY()+1) && b.ToFieldOfsX() > field.CHAR_SIZE/8 {
+		return false
+	}
+	return true
+}
+
+// standsOn reports whether the body stands on a platform at (x, y) with the width in tiles.
+// margin is the extra depth where the body is regarded as standing.
+func (b *Body) standsOn(x, y float64, width int, margin float64) bool {
+	foot := b.Position.Y + field.CHAR_SIZE
+	if foot < y || foot >= y+field.CHAR_SIZE/4+1+margin {
+		return false
+	}
+	if b.Position.X+field.CHAR_SIZE*7/8 <= x {
+		return false
+	}
+	if b.Position.X+field.CHAR_SIZE/8 >= x+float64(width*field.CHAR_SIZE) {
+		return false
+	}
+	return true
+}
+
+// onPlatform returns the moving platform the body stands on, or nil.
+func (b *Body) onPlatform(f *field.Field) *field.Platform {
+	for _, p := range f.Platforms() {
+		x, y := p.Position()
+		// A rising platform might pass the foot in a frame.
+		_, dy := p.Delta()
+		if b.standsOn(x, y, p.Width, math.Abs(dy)) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Carry moves the body together with the moving platform it stood on before the platforms moved.
+// A body that doesn't ride a platform is pushed by the platforms moving sideways into it.
+// Carry must be called after Field.Update.
+func (b *Body) Carry(f *field.Field) {
+	if b.Speed.Y >= 0 && !b.onTile(f) && b.ride(f) {
+		return
+	}
+	b.push(f)
+}
+
+// ride moves the body with the platform it stood on, and reports whether there is such a platform.
+func (b *Body) ride(f *field.Field) bool {
+	for _, p := range f.Platforms() {
+		x, y := p.Position()
+		dx, dy := p.Delta()
+		if !b.standsOn(x-dx, y-dy, p.Width, 0) {
+			continue
+		}
+		b.Position.X += dx
+		b.Position.Y += dy
+		// 壁に押し付けられる
+		if dx < 0 && b.isLeftWall(f) {
+			b.normalizeToLeft()
+		}
+		if dx > 0 && b.isRightWall(f) {
+			b.normalizeToRight()
+		}
+		return true
+	}
+	return false
+}
+
+// push moves the body out of the platforms that moved sideways into it.
+func (b *Body) push(f *field.Field) {
+	for _, p := range f.Platforms() {
+		x, y := p.Position()
+		dx, _ := p.Delta()
+		if dx == 0 {
+			continue
+		}
+		// 上に立っている場合は押さない
+		foot := b.Position.Y + field.CHAR_SIZE
+		if foot < y+field.CHAR_SIZE/4+1 || b.Position.Y >= y+field.CHAR_SIZE {
+			continue
+		}
+		w := float64(p.Width * field.CHAR_SIZE)
+		if b.Position.X+field.CHAR_SIZE*7/8 <= x || b.Position.X+field.CHAR_SIZE/8 >= x+w {
+			continue
+		}
+		// 動く前の床の中心から見て進む向きの側にいれば押される
+		center := b.Position.X + field.CHAR_SIZE/2
+		if dx > 0 && center >= x-dx+w/2 {
+			b.Position.X = x + w - field.CHAR_SIZE/8
+			if b.isRightWall(f) {
+				b.normalizeToRight()
+			}
+		}
+		if dx < 0 && center <= x-dx+w/2 {
+			b.Position.X = x - field.CHAR_SIZE*7/8
+			if b.isLeftWall(f) {
+				b.normalizeToLeft()
+			}
+		}
+	}
+}
+
+func (b *Body) isUpperWallBoth(f *field.Field) bool {
+	if b.ToFieldOfsY() < field.CHAR_SIZE/2 {
+		return false
+	}
+	if f.IsWall(b.ToFieldX(), b.ToFieldY()) && f.IsWall(b.ToFieldX()+1, b.ToFieldY()) {
+		return true
+	}
+	return false
+}
+
+func (b *Body) isUpperWall(f *field.Field) bool {
+	if b.ToFieldOfsY() < field.CHAR_SIZE/2 {
+		return false
+	}
+	if f.IsWall(b.ToFieldX(), b.ToFieldY()) && b.ToFieldOfsX() < field.CHAR_SIZE*7/8 {
+		return true
+	}
+	if f.IsWall(b.ToFieldX()+1, b.ToFieldY()) && b.ToFieldOfsX() > field.CHAR_SIZE/8 {
+		return true
+	}
+	return false
+}
+
+func (b *Body) isLeftWall(f *field.Field) bool {
+	if f.IsWall(b.ToFieldX(), b.ToFieldY()) {
+		return true
+	}
+	if f.IsWall(b.ToFieldX(), b.ToFieldY()+1) && b.ToFieldOfsY() > field.CHAR_SIZE/8 {
+		return true
+	}
+	return false
+}
+
+func (b *Body) isRightWall(f *field.Field) bool {
+	if f.IsWall(b.ToFieldX()+1, b.ToFieldY()) {
+		return true
+	}
+	if f.IsWall(b.ToFieldX()+1, b.ToFieldY()+1) && b.ToFieldOfsY() > field.CHAR_SIZE/8 {
+		return true
+	}
+	return false
+}
+
+func (b *Body) normalizeToRight() {
+	b.Position.X = float64(b.ToFieldX() * field.CHAR_SIZE)
+	b.Speed.X = 0
+}
+
+func (b *Body) normalizeToLeft() {
+	b.Position.X = float64((b.ToFieldX() + 1) * field.CHAR_SIZE)
+	b.Speed.X = 0
+}
+
+func (b *Body) normalizeToUpper() {
+	if b.Speed.Y < 0 {
+		b.Speed.Y = 0
+	}
+	b.Position.Y = float64(field.CHAR_SIZE * (b.ToFieldY() + 1))
+}
+
+func (b *Body) ToFieldX() int {
+	return int(b.Position.X) / field.CHAR_SIZE
+}
+
+func (b *Body) ToFieldY() int {
+	return int(b.Position.Y) / field.CHAR_SIZE
+}
+
+func (b *Body) ToFieldOfsX() int {
+	return int(b.Position.X) % field.CHAR_SIZE
+}
+
+func (b *Body) ToFieldOfsY() int {
+	return int(b.Position.Y) % field.CHAR_SIZE
+}
+
+// Zone returns the physics parameters at the center of the body.
+func (b *Body) Zone(f *field.Field) *fieldtype.Zone {
+	x := int(b.Position.X+field.CHAR_SIZE/2) / field.CHAR_SIZE
+	y := int(b.Position.Y+field.CHAR_SIZE/2) / field.CHAR_SIZE
+	if z := f.GetField(x, y).Tile().Zone; z != nil {
+		return z
+	}
+	return &DefaultZone
+}
+
+// CanJump reports whether the body can jump.
+func (b *Body) CanJump(f *field.Field, jumpMax int) bool {
+	return jumpMax > b.JumpCnt || b.OnWall(f) || b.Zone(f).Swim
+}
+
+// Jump makes the body jump if possible, and reports whether the body jumped.
+func (b *Body) Jump(f *field.Field, jumpMax int) bool {
+	if !b.CanJump(f, jumpMax) {
+		return false
+	}
+	z := b.Zone(f)
+	b.Speed.Y = z.Jump // ジャンプ
+	if !b.OnWall(f) && !z.Swim {
+		b.JumpCnt++
+	}
+
+	if math.Abs(b.Speed.X) < 0.1 {
+		if b.Speed.X < 0 {
+			b.Speed.X -= 0.02
+		}
+		if b.Speed.X > 0 {
+			b.Speed.X += 0.02
+		}
+	}
+	b.JumpedPoint = b.Position
+	return true
+}
+
+// ladderX returns the column of the ladder the body holds.
+func (b *Body) ladderX() int {
+	return int(b.Position.X+field.CHAR_SIZE/2) / field.CHAR_SIZE
+}
+
+// CanClimb reports whether the body can move vertically by dy along a ladder.
+func (b *Body) CanClimb(f *field.Field, dy float64) bool {
+	y := int(b.Position.Y+dy+field.CHAR_SIZE-1) / field.CHAR_SIZE
+	return f.IsClimbable(b.ladderX(), y)
+}
+
+// Climb moves the body vertically by dy along the ladder. The gravity doesn't work while climbing.
+// When the body climbs up to the top of the ladder, the body stands on it.
+//
+// Climb reports whether the body is still on the ladder.
+func (b *Body) Climb(f *field.Field, dy float64) bool {
+	b.Speed = PositionF{}
+	b.JumpCnt = 0
+	defer func() {
+		b.JumpedPoint = b.Position
+	}()
+
+	x := b.ladderX()
+	if b.CanClimb(f, dy) {
+		b.Position.X = float64(x * field.CHAR_SIZE)
+		b.Position.Y += dy
+		return true
+	}
+	if dy >= 0 {
+		return false
+	}
+	// はしごの上に立つ
+	y := int(b.Position.Y+field.CHAR_SIZE-1) / field.CHAR_SIZE
+	if f.IsWall(x, y-1) {
+		return true
+	}
+	b.Position.X = float64(x * field.CHAR_SIZE)
+	b.Position.Y = float64((y - 1) * field.CHAR_SIZE)
+	return false
+}
+
+// JumpOff makes the body jump off the ladder toward dir.
+func (b *Body) JumpOff(f *field.Field, dir int) {
+	b.Direction = dir
+	b.Speed.X = float64(dir) * PLAYER_JUMPOFF_SPEED
+	b.Speed.Y = b.Zone(f).Jump
+	b.JumpedPoint = b.Position
+}
+
+// Knockback makes the body bounce as it is damaged.
+func (b *Body) Knockback() {
+	b.Speed.Y = PLAYER_JUMP
+	b.JumpCnt = -1 // ダメージ・エキストラジャンプ
+}
+
+// Fall moves the body by its speed and the gravity.
+func (b *Body) Fall(f *field.Field) {
+	z := b.Zone(f)
+
+	// 移動＆落下
+	b.Speed.Y += z.Gravity
+	b.Position.X += b.Speed.X
+	b.Position.Y += b.Speed.Y
+
+	if b.Speed.Y > z.FallSpeedMax {
+		b.Speed.Y = z.FallSpeedMax
+	}
+
+	// 水中では落下ダメージを受けない
+	if z.Swim {
+		b.JumpedPoint = b.Position
+	}
+}
+
+// Collide pushes the body out of the walls.
+// If drop is true, the body drops through the floor when possible.
+//
+// Collide reports whether the body is on the floor, and if so, the height the
+// body has fallen from.
+func (b *Body) Collide(f *field.Field, drop bool) (landed bool, fallHeight float64) {
+	// ATARI判定
+	hitLeft := false
+	hitRight := false
+	hitUpper := false
+	if b.OnWall(f) && b.Speed.Y >= 0 {
+		landed = true
+		fallHeight = b.Position.Y - b.JumpedPoint.Y
+
+		if !drop || !b.IsFallable(f) {
+			if b.Speed.Y > 0 {
+				b.Speed.Y = 0
+			}
+			if b.onTile(f) {
+				b.Position.Y = float64(field.CHAR_SIZE * b.ToFieldY())
+			} else {
+				_, y := b.onPlatform(f).Position()
+				b.Position.Y = y - field.CHAR_SIZE
+			}
+			b.JumpCnt = 0
+		}
+
+		b.JumpedPoint = b.Position
+	}
+	if b.isLeftWall(f) && b.Speed.X < 0 {
+		hitLeft = true
+	}
+	if b.isRightWall(f) && b.Speed.X > 0 {
+		hitRight = true
+	}
+	if b.isUpperWall(f) && b.Speed.Y <= 0 {
+		hitUpper = true
+	}
+
+	if hitUpper && !hitLeft && !hitRight {
+		b.normalizeToUpper()
+	}
+	if !hitUpper && hitLeft {
+		b.normalizeToLeft()
+	}
+	if !hitUpper && hitRight {
+		b.normalizeToRight()
+	}
+	if hitUpper && hitRight {
+		if b.isUpperWallBoth(f) {
+			b.normalizeToUpper()
+		} else {
+			if b.ToFieldOfsX() > b.ToFieldOfsY() {
+				b.normalizeToRight()
+			} else {
+				b.normalizeToUpper()
+			}
+		}
+	}
+	if hitUpper && hitLeft {
+		if b.isUpperWallBoth(f) {
+			b.normalizeToUpper()
+		} else {
+			if field.CHAR_SIZE-b.ToFieldOfsX() > b.ToFieldOfsY() {
+				b.normalizeToLeft()
+			} else {
+				b.normalizeToUpper()
+			}
+		}
+	}
+	return
+}
+
+// FallDamage returns the life lost by falling from the height in lunker mode.
+func FallDamage(fallHeight float64) int {
+	damage := 0
+	if fallHeight > LUNKER_JUMP_DAMAGE1 {
+		damage += LIFE_RATIO
+	}
+	if fallHeight > LUNKER_JUMP_DAMAGE2 {
+		damage += LIFE_RATIO * 99
+	}
+	return damage
+}
+
+// Accelerate changes the horizontal speed by the direction and the floor.
+func (b *Body) Accelerate(f *field.Field) {
+	// 床特殊効果
+	z := b.Zone(f)
+	tile := b.OnField(f).Tile()
+	if !tile.Ridable {
+		b.Speed.X = b.Speed.X*(1.0-z.AirAccRatio) + (float64(b.Direction*PLAYER_SPEED)+z.Wind)*z.AirAccRatio
+		return
+	}
+	ratio := PLAYER_GRD_ACCRATIO * tile.Friction
+	b.Speed.X = b.Speed.X*(1.0-ratio) + (float64(b.Direction*PLAYER_SPEED)+tile.Conveyor+z.Wind)*ratio
+}
+
+// OnField returns the type of the floor the body is on.
+func (b *Body) OnField(f *field.Field) fieldtype.FieldType {
+	if !b.OnWall(f) {
+		return fieldtype.FIELD_NONE
+	}
+	if !b.onTile(f) {
+		return b.onPlatform(f).Type
+	}
+	x, y := b.ToFieldX(), b.ToFieldY()
+	if b.ToFieldOfsX() < field.CHAR_SIZE/2 {
+		if f.IsRidable(x, y+1) {
+			return f.GetField(x, y+1)
+		}
+		return f.GetField(x+1, y+1)
+	}
+	if f.IsRidable(x+1, y+1) {
+		return f.GetField(x+1, y+1)
+	}
+	return f.GetField(x, y+1)
+}
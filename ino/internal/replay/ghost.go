@@ -0,0 +1,227 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// GhostVersion is the latest version of the ghost file format.
+const GhostVersion = 1
+
+const ghostMagic = "INOGHOST"
+
+// Ghost is the positions of the player in a play frame by frame, drawn as a ghost in later plays.
+//
+// A ghost file (.inoghost) starts with the magic "INOGHOST" and the header: the version, the game mode,
+// the time of the play and the name of the field. Each frame follows as the field, the differences of
+// the position from the previous frame, the sprite and its animation frame. A field or a sprite is
+// the index of the names appeared so far, followed by the name itself when it appears first.
+type Ghost struct {
+	// Mode is the game mode.
+	Mode int
+
+	// Time is the time to clear the game in frames.
+	Time int
+
+	// Field is the name of the field played.
+	Field string
+
+	// Frames are the states of the player in each frame.
+	Frames []GhostFrame
+}
+
+// GhostFrame is the state of the player in a frame.
+type GhostFrame struct {
+	// Field is the name of the field where the player is.
+	Field string
+
+	// X and Y are the position of the player in pixels.
+	X int
+	Y int
+
+	// Sprite is the name of the sprite of the player, or empty when the player blinks out.
+	Sprite string
+
+	// Anime is the frame of the sprite.
+	Anime int
+}
+
+// LoadGhost reads the ghost file at name.
+func LoadGhost(name string) (*Ghost, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	g, err := ReadGhost(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return g, nil
+}
+
+// SaveGhost writes g to the ghost file at name.
+func SaveGhost(name string, g *Ghost) error {
+	var buf bytes.Buffer
+	if err := g.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(name, buf.Bytes(), 0644)
+}
+
+// ReadGhost reads a ghost from r.
+func ReadGhost(r io.Reader) (*Ghost, error) {
+	br := bufio.NewReader(r)
+	m := make([]byte, len(ghostMagic))
+	if _, err := io.ReadFull(br, m); err != nil || string(m) != ghostMagic {
+		return nil, errors.New("replay: not a ghost file")
+	}
+
+	gr := &ghostReader{r: br}
+	if v := gr.uvarint(); gr.err == nil && (v < 1 || v > GhostVersion) {
+		return nil, fmt.Errorf("replay: unsupported version %d", v)
+	}
+	g := &Ghost{
+		Mode:  int(gr.uvarint()),
+		Time:  int(gr.uvarint()),
+		Field: gr.string(),
+	}
+	n := gr.uvarint()
+	if gr.err != nil {
+		return nil, fmt.Errorf("replay: %w", unexpectedEOF(gr.err))
+	}
+	if n > MaxFrames {
+		return nil, fmt.Errorf("replay: more than %d frames", MaxFrames)
+	}
+
+	var x, y int
+	for i := uint64(0); i < n; i++ {
+		f := gr.name()
+		x += int(gr.varint())
+		y += int(gr.varint())
+		s := gr.name()
+		a := gr.uvarint()
+		if gr.err != nil {
+			return nil, fmt.Errorf("replay: %w", unexpectedEOF(gr.err))
+		}
+		g.Frames = append(g.Frames, GhostFrame{
+			Field:  f,
+			X:      x,
+			Y:      y,
+			Sprite: s,
+			Anime:  int(a),
+		})
+	}
+	return g, nil
+}
+
+type ghostReader struct {
+	r     *bufio.Reader
+	names []string
+	err   error
+}
+
+func (g *ghostReader) uvarint() uint64 {
+	if g.err != nil {
+		return 0
+	}
+	var v uint64
+	v, g.err = binary.ReadUvarint(g.r)
+	return v
+}
+
+func (g *ghostReader) varint() int64 {
+	if g.err != nil {
+		return 0
+	}
+	var v int64
+	v, g.err = binary.ReadVarint(g.r)
+	return v
+}
+
+func (g *ghostReader) string() string {
+	if g.err != nil {
+		return ""
+	}
+	var s string
+	s, g.err = readString(g.r)
+	return s
+}
+
+func (g *ghostReader) name() string {
+	i := g.uvarint()
+	if g.err != nil {
+		return ""
+	}
+	switch {
+	case i < uint64(len(g.names)):
+		return g.names[i]
+	case i == uint64(len(g.names)):
+		s := g.string()
+		g.names = append(g.names, s)
+		return s
+	}
+	g.err = fmt.Errorf("invalid name index %d", i)
+	return ""
+}
+
+// Write writes g in the latest version.
+func (g *Ghost) Write(w io.Writer) error {
+	if len(g.Frames) > MaxFrames {
+		return fmt.Errorf("replay: more than %d frames", MaxFrames)
+	}
+	if len(g.Field) > MaxStringLength {
+		return fmt.Errorf("replay: too long field name: %d bytes", len(g.Field))
+	}
+	for _, f := range g.Frames {
+		if len(f.Field) > MaxStringLength || len(f.Sprite) > MaxStringLength {
+			return fmt.Errorf("replay: too long name in a frame")
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(x uint64) {
+		bw.Write(buf[:binary.PutUvarint(buf[:], x)])
+	}
+	putVarint := func(x int64) {
+		bw.Write(buf[:binary.PutVarint(buf[:], x)])
+	}
+	putString := func(s string) {
+		putUvarint(uint64(len(s)))
+		bw.WriteString(s)
+	}
+	names := map[string]int{}
+	putName := func(s string) {
+		if i, ok := names[s]; ok {
+			putUvarint(uint64(i))
+			return
+		}
+		names[s] = len(names)
+		putUvarint(uint64(names[s]))
+		putString(s)
+	}
+
+	bw.WriteString(ghostMagic)
+	putUvarint(GhostVersion)
+	putUvarint(uint64(g.Mode))
+	putUvarint(uint64(g.Time))
+	putString(g.Field)
+	putUvarint(uint64(len(g.Frames)))
+
+	var x, y int
+	for _, f := range g.Frames {
+		putName(f.Field)
+		putVarint(int64(f.X - x))
+		putVarint(int64(f.Y - y))
+		putName(f.Sprite)
+		putUvarint(uint64(f.Anime))
+		x, y = f.X, f.Y
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,180 @@
+// Package replay reads and writes the recordings of plays: replay files (.inoreplay), which record
+// the inputs frame by frame, and ghost files (.inoghost), which record the positions of the player.
+//
+// All the integers in the files are varints.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Version is the latest version of the replay file format.
+const Version = 1
+
+const magic = "INOREPLAY"
+
+const (
+	// MaxFrames is the maximum number of the frames in a file, 24 hours at 60 FPS.
+	MaxFrames = 24 * 60 * 60 * 60
+
+	// MaxStringLength is the maximum length of a string like a field name in a file.
+	MaxStringLength = 4096
+)
+
+// readString reads a string prefixed by its length.
+func readString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if n > MaxStringLength {
+		return "", fmt.Errorf("too long string: %d bytes", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF, since a file must not end in the middle.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Replay is a recorded play.
+//
+// A replay file starts with the magic "INOREPLAY" and the header: the version, the game mode,
+// the random seed and the name of the field. The frames follow as runs, each of which is
+// the number of the frames and the buttons pressed in them.
+type Replay struct {
+	// Mode is the game mode.
+	Mode int
+
+	// Seed is the seed of the random numbers at the start of the play.
+	Seed int64
+
+	// Field is the name of the field played.
+	Field string
+
+	// Frames are the buttons pressed in each frame as bits.
+	Frames []byte
+}
+
+// Load reads the replay file at name.
+func Load(name string) (*Replay, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return r, nil
+}
+
+// Save writes r to the replay file at name.
+func Save(name string, r *Replay) error {
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(name, buf.Bytes(), 0644)
+}
+
+// Read reads a replay from r.
+func Read(r io.Reader) (*Replay, error) {
+	br := bufio.NewReader(r)
+	m := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, m); err != nil || string(m) != magic {
+		return nil, errors.New("replay: not a replay file")
+	}
+
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", unexpectedEOF(err))
+	}
+	if version < 1 || version > Version {
+		return nil, fmt.Errorf("replay: unsupported version %d", version)
+	}
+
+	var rep Replay
+	mode, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", unexpectedEOF(err))
+	}
+	rep.Mode = int(mode)
+	if rep.Seed, err = binary.ReadVarint(br); err != nil {
+		return nil, fmt.Errorf("replay: %w", unexpectedEOF(err))
+	}
+	if rep.Field, err = readString(br); err != nil {
+		return nil, fmt.Errorf("replay: %w", unexpectedEOF(err))
+	}
+
+	for {
+		run, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("replay: %w", err)
+		}
+		if run > uint64(MaxFrames-len(rep.Frames)) {
+			return nil, fmt.Errorf("replay: more than %d frames", MaxFrames)
+		}
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("replay: %w", io.ErrUnexpectedEOF)
+		}
+		for i := uint64(0); i < run; i++ {
+			rep.Frames = append(rep.Frames, b)
+		}
+	}
+	return &rep, nil
+}
+
+// Write writes r in the latest version.
+func (r *Replay) Write(w io.Writer) error {
+	if len(r.Field) > MaxStringLength {
+		return fmt.Errorf("replay: too long field name: %d bytes", len(r.Field))
+	}
+	if len(r.Frames) > MaxFrames {
+		return fmt.Errorf("replay: more than %d frames", MaxFrames)
+	}
+
+	bw := bufio.NewWriter(w)
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(x uint64) {
+		bw.Write(buf[:binary.PutUvarint(buf[:], x)])
+	}
+
+	bw.WriteString(magic)
+	putUvarint(Version)
+	putUvarint(uint64(r.Mode))
+	bw.Write(buf[:binary.PutVarint(buf[:], r.Seed)])
+	putUvarint(uint64(len(r.Field)))
+	bw.WriteString(r.Field)
+
+	// 同じ入力が続くフレームをまとめる
+	for i := 0; i < len(r.Frames); {
+		j := i + 1
+		for j < len(r.Frames) && r.Frames[j] == r.Frames[i] {
+			j++
+		}
+		putUvarint(uint64(j - i))
+		bw.WriteByte(r.Frames[i])
+		i = j
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,198 @@
+package replay_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/replay"
+)
+
+func testReplay() *replay.Replay {
+	r := &replay.Replay{
+		Mode:  1,
+		Seed:  -1234567890123,
+		Field: "inovation.inofield",
+	}
+	for i := 0; i < 3000; i++ {
+		r.Frames = append(r.Frames, byte((i/97)%32))
+	}
+	return r
+}
+
+func testGhost() *replay.Ghost {
+	g := &replay.Ghost{
+		Mode:  0,
+		Time:  4000,
+		Field: "inovation.inofield",
+	}
+	for i := 0; i < 3000; i++ {
+		f := "inovation.inofield"
+		if i > 2000 {
+			f = "next.inofield"
+		}
+		s := "player_right"
+		if i%50 < 10 {
+			s = ""
+		}
+		g.Frames = append(g.Frames, replay.GhostFrame{
+			Field:  f,
+			X:      100 + i%37,
+			Y:      2000 - i,
+			Sprite: s,
+			Anime:  i % 2,
+		})
+	}
+	return g
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	r := testReplay()
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := replay.Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, r) {
+		t.Errorf("got %+v, want %+v", got, r)
+	}
+}
+
+func TestGhostRoundTrip(t *testing.T) {
+	g := testGhost()
+	var buf bytes.Buffer
+	if err := g.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := replay.ReadGhost(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, g) {
+		t.Errorf("the decoded ghost differs from the encoded one")
+	}
+}
+
+func TestReplayTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReplay().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// 最後のランの途中で切れる
+	if _, err := replay.Read(bytes.NewReader(data[:len(data)-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	// ヘッダの途中で切れる
+	for _, n := range []int{0, 5, len("INOREPLAY"), len("INOREPLAY") + 3} {
+		if _, err := replay.Read(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("%d bytes: got no error", n)
+		}
+	}
+}
+
+func TestGhostTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testGhost().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	for _, n := range []int{0, len("INOGHOST"), len("INOGHOST") + 4, len(data) / 2, len(data) - 1} {
+		if _, err := replay.ReadGhost(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("%d bytes: got no error", n)
+		}
+	}
+}
+
+func uvarints(xs ...uint64) []byte {
+	var b []byte
+	for _, x := range xs {
+		b = binary.AppendUvarint(b, x)
+	}
+	return b
+}
+
+func TestReplayOversized(t *testing.T) {
+	header := append([]byte("INOREPLAY"), uvarints(replay.Version, 0)...)
+	header = binary.AppendVarint(header, 0)
+
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "field name",
+			data: append(append([]byte{}, header...), uvarints(1<<62)...),
+			want: "too long string",
+		},
+		{
+			name: "run",
+			data: append(append(append([]byte{}, header...), uvarints(0, 1<<40)...), 0),
+			want: "more than",
+		},
+		{
+			name: "total frames",
+			data: append(append(append(append([]byte{}, header...), uvarints(0, replay.MaxFrames)...), 0), append(uvarints(1), 0)...),
+			want: "more than",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := replay.Read(bytes.NewReader(c.data))
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("got %v, want an error with %q", err, c.want)
+			}
+		})
+	}
+}
+
+func TestGhostOversized(t *testing.T) {
+	header := append([]byte("INOGHOST"), uvarints(replay.GhostVersion, 0, 100)...)
+
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "field name",
+			data: append(append([]byte{}, header...), uvarints(1<<62)...),
+			want: "too long string",
+		},
+		{
+			name: "frames",
+			data: append(append([]byte{}, header...), uvarints(0, 1<<40)...),
+			want: "more than",
+		},
+		{
+			name: "frame name",
+			data: append(append([]byte{}, header...), uvarints(0, 1, 0, 1<<62)...),
+			want: "too long string",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := replay.ReadGhost(bytes.NewReader(c.data))
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("got %v, want an error with %q", err, c.want)
+			}
+		})
+	}
+}
+
+func TestWriteOversized(t *testing.T) {
+	r := &replay.Replay{
+		Field: strings.Repeat("a", replay.MaxStringLength+1),
+	}
+	if err := r.Write(io.Discard); err == nil {
+		t.Errorf("got no error for a too long field name")
+	}
+}
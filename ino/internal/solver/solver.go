@@ -0,0 +1,468 @@
+// Package solver searches the states of the player to find which items in a
+// field can be collected.
+//
+// The solver simulates the player frame by frame with the rules in the
+// physics package, trying every combination of the inputs. States are
+// deduplicated by their quantized positions and speeds, so a reachable item
+// always comes with a real route, but an item reported as unreachable might
+// be reachable in a way the quantization hides.
+//
+// The solver searches only one field. Warps to other fields are not followed.
+// Moving platforms are regarded as staying at their first points, crumbling
+// blocks never crumble, switches are never toggled, and enemies are ignored.
+// Ladders are never climbed, though their tops are floors.
+package solver
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+	"github.com/hajimehoshi/go-inovation/ino/internal/physics"
+)
+
+// hiddenSecretItemCount is the number of items required to reveal FIELD_ITEM_OMEGA.
+// This must be consistent with GameData.IsHiddenSecret.
+const hiddenSecretItemCount = 15
+
+type Options struct {
+	// JumpMax and LifeMax are the initial values of the game data.
+	JumpMax int
+	LifeMax int
+
+	// Lunker enables the fall damage.
+	Lunker bool
+
+	// Requires enables finding the power-ups and the lives required for each
+	// item. This searches again for each power-up and life.
+	Requires bool
+
+	// MaxStates limits the number of states in one search. 0 means no limit.
+	MaxStates int
+}
+
+type Item struct {
+	Type      fieldtype.FieldType
+	Position  image.Point
+	Reachable bool
+
+	// Frame is the earliest frame when the item is reached.
+	Frame int
+
+	// Collected is the power-ups and the lives collected before the item on
+	// the earliest route found.
+	Collected []image.Point
+
+	// Requires is the power-ups and the lives without which the item is
+	// unreachable. Requires is filled only when Options.Requires is true.
+	Requires []image.Point
+}
+
+type Result struct {
+	Items []*Item
+
+	// Order is the reachable power-ups and the lives in an order they can be collected.
+	Order []image.Point
+
+	// States is the number of searched states.
+	States int
+
+	// Complete reports whether every search finished within MaxStates.
+	Complete bool
+}
+
+type input struct {
+	direction int
+	action    bool
+	down      bool
+}
+
+type state struct {
+	body    physics.Body
+	life    int
+	muteki  bool
+	wait    int
+	held    bool
+	items   uint64
+	jumpMax int
+	lifeMax int
+}
+
+// key is the quantized physical state.
+type key uint64
+
+// The quantization units of the key.
+const (
+	positionUnit = 8
+	speedXUnit   = 0.5
+	speedYUnit   = 0.5
+	waitUnit     = 10
+	fallUnit     = 8
+)
+
+// value is the rest of the state, which is compared by dominance:
+// more collected items, more life and more remaining jumps are never worse.
+type value struct {
+	items uint64
+	life  int
+	jumps int
+}
+
+func (v value) dominates(w value) bool {
+	return v.items&w.items == w.items && v.life >= w.life && v.jumps >= w.jumps
+}
+
+// node is a state in the search.
+//
+// While a state stays in the same key as its parent, the state keeps the
+// parent's input instead of branching again. Otherwise a slowly moving state
+// would be pruned as visited by its own parent.
+type node struct {
+	state state
+	input input
+	chain int
+}
+
+// maxChain is the maximum number of frames a node can stay in the same key.
+const maxChain = 120
+
+type reach struct {
+	frame int
+	items uint64
+}
+
+type search struct {
+	field     *field.Field
+	opts      *Options
+	bits      map[image.Point]int
+	forbidden uint64
+	reached   map[image.Point]reach
+	visited   map[key][]value
+}
+
+func (s *search) key(st *state) key {
+	x := int(math.Floor(st.body.Position.X / positionUnit))
+	y := int(math.Floor(st.body.Position.Y / positionUnit))
+	vx := int(math.Round(st.body.Speed.X / speedXUnit))
+	vy := int(math.Round(st.body.Speed.Y / speedYUnit))
+	wait := 0
+	if st.muteki {
+		wait = st.wait/waitUnit + 1
+	}
+	fall := 0
+	if s.opts.Lunker {
+		fall = int(math.Min((st.body.Position.Y-st.body.JumpedPoint.Y)/fallUnit, 16))
+	}
+	k := uint64(uint16(x))
+	k |= uint64(uint16(y)) << 16
+	k |= uint64(uint8(vx)) << 32
+	k |= uint64(uint8(vy)) << 40
+	k |= uint64(uint8(st.body.Direction)&0x3) << 48
+	k |= uint64(wait&0xf) << 50
+	k |= uint64(uint8(fall)&0x3f) << 54
+	return key(k)
+}
+
+func (s *search) value(st *state) value {
+	return value{
+		items: st.items,
+		life:  st.life / physics.LIFE_RATIO,
+		jumps: st.jumpMax - st.body.JumpCnt,
+	}
+}
+
+// visit records the state and reports whether the state is new.
+func (s *search) visit(st *state) bool {
+	k := s.key(st)
+	v := s.value(st)
+	vs := s.visited[k]
+	for _, w := range vs {
+		if w.dominates(v) {
+			return false
+		}
+	}
+	n := 0
+	for _, w := range vs {
+		if !v.dominates(w) {
+			vs[n] = w
+			n++
+		}
+	}
+	s.visited[k] = append(vs[:n], v)
+	return true
+}
+
+// checkCollision corresponds to Player.checkCollision.
+func (s *search) checkCollision(st *state, frame int) {
+	x, y := st.body.ToFieldX(), st.body.ToFieldY()
+	for xx := 0; xx < 2; xx++ {
+		for yy := 0; yy < 2; yy++ {
+			p := image.Pt(x+xx, y+yy)
+			if s.field.IsItem(p.X, p.Y) {
+				b, ok := s.bits[p]
+				if ok && st.items&(1<<b) != 0 {
+					// The item is already collected.
+					continue
+				}
+				if _, ok := s.reached[p]; !ok {
+					s.reached[p] = reach{
+						frame: frame,
+						items: st.items,
+					}
+				}
+				if !ok || s.forbidden&(1<<b) != 0 {
+					continue
+				}
+				st.items |= 1 << b
+				switch s.field.GetField(p.X, p.Y) {
+				case fieldtype.FIELD_ITEM_POWERUP:
+					st.jumpMax++
+				case fieldtype.FIELD_ITEM_LIFE:
+					st.lifeMax++
+					st.life = st.lifeMax * physics.LIFE_RATIO
+				}
+				// The action key must be pressed to close the item message.
+				st.held = true
+				return
+			}
+			// トゲ(ダメージ)
+			if s.field.IsSpike(p.X, p.Y) {
+				st.muteki = true
+				st.wait = 0
+				st.life -= physics.LIFE_RATIO
+				st.body.Knockback()
+				return
+			}
+		}
+	}
+}
+
+// step corresponds to Player.Update. step reports whether the player is still alive.
+func (s *search) step(st *state, in input, frame int) bool {
+	muteki := st.muteki
+
+	// moveByInput
+	if in.direction != 0 {
+		st.body.Direction = in.direction
+	}
+	if in.action && !st.held && !in.down {
+		st.body.Jump(s.field, st.jumpMax)
+	}
+	st.held = in.action
+
+	// moveNormal
+	st.body.Fall(s.field)
+	if !st.muteki {
+		s.checkCollision(st, frame)
+	}
+	if landed, h := st.body.Collide(s.field, in.action && in.down); landed && s.opts.Lunker {
+		if d := physics.FallDamage(h); d > 0 {
+			st.muteki = true
+			st.wait = 0
+			st.life -= d
+		}
+	}
+	st.body.Accelerate(s.field)
+
+	if muteki {
+		st.wait++
+		if st.wait > physics.MUTEKI_INTERVAL {
+			st.muteki = false
+		}
+	} else if st.life < st.lifeMax*physics.LIFE_RATIO {
+		st.life++
+	}
+	return st.life >= physics.LIFE_RATIO
+}
+
+// inputs returns the inputs worth trying at the state.
+func (s *search) inputs(st *state) []input {
+	jump := !st.held && st.body.CanJump(s.field, st.jumpMax)
+	drop := st.body.IsFallable(s.field)
+
+	dirs := []int{-1, 1}
+	if st.body.Direction == 0 {
+		dirs = append(dirs, 0)
+	}
+	var ins []input
+	for _, d := range dirs {
+		ins = append(ins, input{direction: d})
+		if jump {
+			ins = append(ins, input{direction: d, action: true})
+		}
+		if drop {
+			ins = append(ins, input{direction: d, action: true, down: true})
+		}
+	}
+	return ins
+}
+
+// run searches all the states from start, and reports whether the search is complete.
+func (s *search) run(start state) bool {
+	s.visit(&start)
+	current := []node{{state: start}}
+	var next []node
+	for frame := 1; len(current) > 0; frame++ {
+		for i := range current {
+			n := &current[i]
+			var ins []input
+			if n.chain > 0 {
+				ins = []input{n.input}
+			} else {
+				ins = s.inputs(&n.state)
+			}
+			pk, pv := s.key(&n.state), s.value(&n.state)
+			for _, in := range ins {
+				st := n.state
+				if !s.step(&st, in, frame) {
+					continue
+				}
+				if s.key(&st) == pk && s.value(&st) == pv {
+					if n.chain < maxChain && st != n.state {
+						next = append(next, node{state: st, input: in, chain: n.chain + 1})
+					}
+					continue
+				}
+				if !s.visit(&st) {
+					continue
+				}
+				next = append(next, node{state: st, input: in})
+			}
+		}
+		if s.opts.MaxStates > 0 && len(s.visited) > s.opts.MaxStates {
+			return false
+		}
+		current, next = next, current[:0]
+	}
+	return true
+}
+
+func Solve(f *field.Field, opts *Options) (*Result, error) {
+	if len(f.Find(fieldtype.FIELD_ITEM_STARTPOINT)) != 1 {
+		return nil, errors.New("solver: the field must have exactly one start point")
+	}
+	f = f.Clone()
+	x, y := f.GetStartPoint()
+	start := state{
+		body:    physics.NewBody(physics.PositionF{X: float64(x), Y: float64(y)}),
+		life:    opts.LifeMax * physics.LIFE_RATIO,
+		jumpMax: opts.JumpMax,
+		lifeMax: opts.LifeMax,
+	}
+
+	items := map[image.Point]*Item{}
+	var powers []image.Point
+	for t := fieldtype.FIELD_ITEM_POWERUP; t < fieldtype.FIELD_ITEM_STARTPOINT; t++ {
+		for _, p := range f.Find(t) {
+			items[p] = &Item{
+				Type:     t,
+				Position: p,
+			}
+			if t == fieldtype.FIELD_ITEM_POWERUP || t == fieldtype.FIELD_ITEM_LIFE {
+				powers = append(powers, p)
+			}
+		}
+	}
+	if len(powers) > 64 {
+		return nil, errors.New("solver: too many power-ups and lives")
+	}
+	bits := map[image.Point]int{}
+	for i, p := range powers {
+		bits[p] = i
+	}
+
+	r := &Result{
+		Complete: true,
+	}
+	run := func(forbidden uint64) map[image.Point]reach {
+		s := &search{
+			field:     f,
+			opts:      opts,
+			bits:      bits,
+			forbidden: forbidden,
+			reached:   map[image.Point]reach{},
+			visited:   map[key][]value{},
+		}
+		if !s.run(start) {
+			r.Complete = false
+		}
+		for _, vs := range s.visited {
+			r.States += len(vs)
+		}
+
+		// FIELD_ITEM_OMEGA is hidden until enough items are collected.
+		var count int
+		for p := range s.reached {
+			switch items[p].Type {
+			case fieldtype.FIELD_ITEM_POWERUP, fieldtype.FIELD_ITEM_LIFE, fieldtype.FIELD_ITEM_OMEGA:
+			default:
+				count++
+			}
+		}
+		if count < hiddenSecretItemCount {
+			for p := range s.reached {
+				if items[p].Type == fieldtype.FIELD_ITEM_OMEGA {
+					delete(s.reached, p)
+				}
+			}
+		}
+		return s.reached
+	}
+
+	for p, rc := range run(0) {
+		it := items[p]
+		it.Reachable = true
+		it.Frame = rc.frame
+		for i, pp := range powers {
+			if rc.items&(1<<i) != 0 {
+				it.Collected = append(it.Collected, pp)
+			}
+		}
+	}
+
+	if opts.Requires {
+		// An item requires a power-up if the item is unreachable without the power-up.
+		for i, pp := range powers {
+			if !items[pp].Reachable {
+				continue
+			}
+			reached := run(1 << i)
+			for p, it := range items {
+				if _, ok := reached[p]; !ok && it.Reachable && p != pp {
+					it.Requires = append(it.Requires, pp)
+				}
+			}
+		}
+	}
+
+	for _, it := range items {
+		r.Items = append(r.Items, it)
+	}
+	sort.Slice(r.Items, func(i, j int) bool {
+		a, b := r.Items[i], r.Items[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Position.Y != b.Position.Y {
+			return a.Position.Y < b.Position.Y
+		}
+		return a.Position.X < b.Position.X
+	})
+
+	for _, p := range powers {
+		if items[p].Reachable {
+			r.Order = append(r.Order, p)
+		}
+	}
+	sort.Slice(r.Order, func(i, j int) bool {
+		a, b := items[r.Order[i]], items[r.Order[j]]
+		if len(a.Requires) != len(b.Requires) {
+			return len(a.Requires) < len(b.Requires)
+		}
+		return a.Frame < b.Frame
+	})
+	return r, nil
+}
@@ -0,0 +1,101 @@
+package solver_test
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
+	"github.com/hajimehoshi/go-inovation/ino/internal/solver"
+)
+
+// testField has a reachable item (fuji), an item too high without the power-up (bushi),
+// and an item behind a wall (apple).
+const testField = `version 1
+size 17 9
+legend '#' block
+legend '.' none
+legend 'S' item_startpoint
+legend 'P' item_powerup
+legend 'F' item_fuji
+legend 'B' item_bushi
+legend 'A' item_apple
+map
+#################
+#..........#....#
+#..........#....#
+#....B.....#....#
+#..........#....#
+#..........#....#
+#..........#....#
+#S.F...P...#..A.#
+#################
+`
+
+func TestSolve(t *testing.T) {
+	f, err := field.Parse(strings.NewReader(testField), "test.inofield")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// NewGameData の通常モードと同じ
+	r, err := solver.Solve(f, &solver.Options{
+		JumpMax:  0,
+		LifeMax:  3,
+		Requires: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Complete {
+		t.Errorf("Complete: got false, want true")
+	}
+
+	powerup := image.Pt(7, 7)
+	want := map[image.Point]struct {
+		reachable bool
+		requires  []image.Point
+	}{
+		powerup:         {true, nil},
+		image.Pt(3, 7):  {true, nil},
+		image.Pt(5, 3):  {true, []image.Point{powerup}},
+		image.Pt(14, 7): {false, nil},
+	}
+	if len(r.Items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(r.Items), len(want))
+	}
+	for _, it := range r.Items {
+		w, ok := want[it.Position]
+		if !ok {
+			t.Errorf("unexpected item %s at %v", it.Type, it.Position)
+			continue
+		}
+		if it.Reachable != w.reachable {
+			t.Errorf("%s at %v: Reachable: got %t, want %t", it.Type, it.Position, it.Reachable, w.reachable)
+		}
+		if !reflect.DeepEqual(it.Requires, w.requires) {
+			t.Errorf("%s at %v: Requires: got %v, want %v", it.Type, it.Position, it.Requires, w.requires)
+		}
+	}
+	if !reflect.DeepEqual(r.Order, []image.Point{powerup}) {
+		t.Errorf("Order: got %v, want %v", r.Order, []image.Point{powerup})
+	}
+}
+
+func TestSolveMaxStates(t *testing.T) {
+	f, err := field.Parse(strings.NewReader(testField), "test.inofield")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := solver.Solve(f, &solver.Options{
+		JumpMax:   0,
+		LifeMax:   3,
+		MaxStates: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Complete {
+		t.Errorf("Complete: got true, want false")
+	}
+}
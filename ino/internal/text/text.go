@@ -1,6 +1,12 @@
 package text
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
 	"golang.org/x/text/language"
 )
 
@@ -15,6 +21,7 @@ const (
 	TextIDEndingScore1
 	TextIDEndingScore2
 	TextIDEndingScore3
+	TextIDEndingScore4
 	TextIDSecretCommand
 	TextIDSecretClear
 	TextIDItemPowerUp
@@ -160,6 +167,7 @@ HTML5　いしょく
 		TextIDEndingScore1: "せいせき　はぴょう",
 		TextIDEndingScore2: "かくとく　あいてむ",
 		TextIDEndingScore3: "くりあ　たいむ",
+		TextIDEndingScore4: "みす　かいすう",
 		TextIDSecretCommand: `たいとるで
 
 ひだり ひだり ひだり
@@ -393,6 +401,7 @@ EVZO-END get bath`,
 		TextIDEndingScore1: "Results",
 		TextIDEndingScore2: "You Got ICONS",
 		TextIDEndingScore3: "Clear Time",
+		TextIDEndingScore4: "Deaths",
 		TextIDSecretCommand: `at Title Screen
 
 L L L
@@ -489,6 +498,74 @@ REAL MEN!!`,
 	},
 }
 
+// names are the names of the texts in the text files of mods.
+var names = map[TextID]string{
+	TextIDStart:         "start",
+	TextIDStartLunker:   "start_lunker",
+	TextIDStartTouch:    "start_touch",
+	TextIDOpening:       "opening",
+	TextIDEnding:        "ending",
+	TextIDEndingScore1:  "ending_score1",
+	TextIDEndingScore2:  "ending_score2",
+	TextIDEndingScore3:  "ending_score3",
+	TextIDEndingScore4:  "ending_score4",
+	TextIDSecretCommand: "secret_command",
+	TextIDSecretClear:   "secret_clear",
+	TextIDItemPowerUp:   "item_powerup",
+	TextIDItemFuji:      "item_fuji",
+	TextIDItemBushi:     "item_bushi",
+	TextIDItemApple:     "item_apple",
+	TextIDItemV:         "item_v",
+	TextIDItemTaka:      "item_taka",
+	TextIDItemShoulder:  "item_shoulder",
+	TextIDItemDagger:    "item_dagger",
+	TextIDItemKatakata:  "item_katakata",
+	TextIDItemNasu:      "item_nasu",
+	TextIDItemBonus:     "item_bonus",
+	TextIDItemNurse:     "item_nurse",
+	TextIDItemNazuna:    "item_nazuna",
+	TextIDItemGameHell:  "item_gamehell",
+	TextIDItemGundam:    "item_gundam",
+	TextIDItemPoed:      "item_poed",
+	TextIDItemMilestone: "item_milestone",
+	TextIDItem1Yen:      "item_1yen",
+	TextIDItemTriangle:  "item_triangle",
+	TextIDItemOmega:     "item_omega",
+	TextIDItemLife:      "item_life",
+}
+
+// Load replaces the texts with the ones in the text files text/<language>.json in fsys, like text/en.json.
+// A text file is a JSON object from the names of the texts like "start" to the texts.
+// The texts missing in the files are kept.
+func Load(fsys fs.FS) error {
+	ids := map[string]TextID{}
+	for id, name := range names {
+		ids[name] = id
+	}
+	for lang := range texts {
+		filename := path.Join("text", lang.String()+".json")
+		bs, err := fs.ReadFile(fsys, filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var m map[string]string
+		if err := json.Unmarshal(bs, &m); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		for name, str := range m {
+			id, ok := ids[name]
+			if !ok {
+				return fmt.Errorf("%s: unknown text %q", filename, name)
+			}
+			texts[lang][id] = str
+		}
+	}
+	return nil
+}
+
 func Get(lang language.Tag, id TextID) string {
 	return texts[lang][id]
 }
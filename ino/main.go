@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image/color"
 	"math/rand"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -13,8 +14,10 @@ import (
 
 	"github.com/hajimehoshi/go-inovation/ino/internal/audio"
 	"github.com/hajimehoshi/go-inovation/ino/internal/draw"
+	"github.com/hajimehoshi/go-inovation/ino/internal/field"
 	"github.com/hajimehoshi/go-inovation/ino/internal/font"
 	"github.com/hajimehoshi/go-inovation/ino/internal/input"
+	"github.com/hajimehoshi/go-inovation/ino/internal/replay"
 	"github.com/hajimehoshi/go-inovation/ino/internal/text"
 )
 
@@ -38,6 +41,8 @@ const (
 	GAMESTATE_MSG_REQ_ENDING
 	GAMESTATE_MSG_REQ_SECRET_COMMAND
 	GAMESTATE_MSG_REQ_SECRET_CLEAR
+	GAMESTATE_MSG_REQ_CONTINUE
+	GAMESTATE_MSG_REQ_OPTIONS
 )
 
 type TitleScene struct {
@@ -47,6 +52,7 @@ type TitleScene struct {
 	offsetY       int
 	lunkerMode    bool
 	lunkerCommand int
+	hasSession    bool
 }
 
 func init() {
@@ -55,6 +61,9 @@ func init() {
 
 func (t *TitleScene) Update(game *Game) {
 	t.timer++
+	if t.timer == 1 {
+		t.hasSession = hasSession()
+	}
 	if t.timer%5 == 0 {
 		t.offsetX = rand.Intn(5) - 3
 		t.offsetY = rand.Intn(5) - 3
@@ -92,13 +101,20 @@ func (t *TitleScene) Update(game *Game) {
 		t.lunkerMode = !t.lunkerMode
 	}
 
+	if input.Current().IsOptionsSwitcherPressed() {
+		t.gameStateMsg = GAMESTATE_MSG_REQ_OPTIONS
+	}
+
+	if t.hasSession && input.Current().IsContinueSwitcherPressed() {
+		t.gameStateMsg = GAMESTATE_MSG_REQ_CONTINUE
+	}
+
+	if input.Current().IsGhostSwitcherPressed() {
+		game.ghost = nextGhost(game.ghost, 1)
+	}
+
 	if input.Current().IsLanguageSwitcherPressed() {
-		switch game.lang {
-		case language.Japanese:
-			game.lang = language.English
-		case language.English:
-			game.lang = language.Japanese
-		}
+		game.lang = nextLanguage(game.lang)
 	}
 
 	switch game.lang {
@@ -114,11 +130,11 @@ func (t *TitleScene) Draw(screen *ebiten.Image, game *Game) {
 	clr := color.Black
 	if !game.transparent {
 		if t.lunkerMode {
-			draw.Draw(screen, "bg", 0, 0, 0, 240, draw.ScreenWidth, draw.ScreenHeight)
+			draw.DrawSprite(screen, "bg_lunker", 0, 0, 0)
 			textID = text.TextIDStartLunker
 			clr = color.White
 		} else {
-			draw.Draw(screen, "bg", 0, 0, 0, 0, draw.ScreenWidth, draw.ScreenHeight)
+			draw.DrawSprite(screen, "bg", 0, 0, 0)
 			if input.Current().IsTouchEnabled() {
 				textID = text.TextIDStartTouch
 			}
@@ -131,11 +147,22 @@ func (t *TitleScene) Draw(screen *ebiten.Image, game *Game) {
 	font.DrawText(screen, str, x, y, clr)
 
 	// Draw the title.
-	key := "msg_" + game.lang.String()
-	draw.Draw(screen, key, (draw.ScreenWidth-256)/2, 32+(draw.ScreenHeight-240)/2, 0, 0, 256, 48)
+	draw.DrawSprite(screen, "title_"+game.lang.String(), 0, (draw.ScreenWidth-256)/2, 32+(draw.ScreenHeight-240)/2)
 
 	// Draw the language switcher.
 	font.DrawText(screen, "Language", 320-48, 0, color.RGBA{0x80, 0x80, 0x80, 0xff})
+
+	// Draw the options button.
+	font.DrawText(screen, "Options", 0, 0, color.RGBA{0x80, 0x80, 0x80, 0xff})
+
+	// Draw the ghost switcher above the touch keys.
+	font.DrawText(screen, ghostLabel(game.ghost), 0, draw.ScreenHeight-64-font.LineHeight, color.RGBA{0x80, 0x80, 0x80, 0xff})
+
+	// Draw the continue button.
+	if t.hasSession {
+		const str = "Continue"
+		font.DrawText(screen, str, draw.ScreenWidth-font.Width(str), draw.ScreenHeight-64-font.LineHeight, color.RGBA{0x80, 0x80, 0x80, 0xff})
+	}
 }
 
 func (t *TitleScene) Msg() GameStateMsg {
@@ -167,7 +194,7 @@ func (o *OpeningScene) Update(game *Game) {
 
 func (o *OpeningScene) Draw(screen *ebiten.Image, game *Game) {
 	if !game.transparent {
-		draw.Draw(screen, "bg", 0, 0, 0, 480, 320, 240)
+		draw.DrawSprite(screen, "bg_story", 0, 0, 0)
 	}
 
 	if _, ok := o.texts[game.lang]; !ok {
@@ -239,7 +266,7 @@ func (e *EndingScene) Update(game *Game) {
 
 func (e *EndingScene) Draw(screen *ebiten.Image, game *Game) {
 	if !game.transparent {
-		draw.Draw(screen, "bg", 0, 0, 0, 480, 320, 240)
+		draw.DrawSprite(screen, "bg_story", 0, 0, 0)
 	}
 
 	switch e.state {
@@ -265,6 +292,12 @@ func (e *EndingScene) Draw(screen *ebiten.Image, game *Game) {
 			text.Get(game.lang, text.TextIDEndingScore3),
 			fmt.Sprintf("%.2f", float64(game.gameData.TimeInFrame())/60),
 		}
+		if !game.gameData.permadeath {
+			lines = append(lines,
+				"",
+				text.Get(game.lang, text.TextIDEndingScore4),
+				strconv.Itoa(game.gameData.Deaths()))
+		}
 		for i, line := range lines {
 			x := (draw.ScreenWidth - font.Width(line)) / 2
 			font.DrawText(screen, line, x, (draw.ScreenHeight-160)/2+16*i, color.Black)
@@ -304,7 +337,7 @@ func (s *SecretScene) Update(game *Game) {
 
 func (s *SecretScene) Draw(screen *ebiten.Image, game *Game) {
 	if !game.transparent {
-		draw.Draw(screen, "bg", 0, 0, 0, 240, 320, 240)
+		draw.DrawSprite(screen, "bg_lunker", 0, 0, 0)
 	}
 	var textID text.TextID
 	switch s.secretType {
@@ -330,27 +363,158 @@ func (s *SecretScene) Msg() GameStateMsg {
 type GameScene struct {
 	gameStateMsg GameStateMsg
 	player       *Player
+
+	// entities are the entities in each field, spawned when the player enters the field first.
+	entities map[string][]Entity
+
+	// controller drives the player with the buttons of each frame,
+	// so that a play and its replay behave in the same way.
+	controller *input.FrameController
+
+	// replay is the replay played back instead of the input, or nil.
+	replay *replay.Replay
+
+	// frame is the number of the frames played.
+	frame int
+
+	// recording is the replay being recorded, or nil.
+	recording *replay.Replay
+
+	// ghost is the ghost to race, or nil.
+	ghost *replay.Ghost
+
+	// run is the ghost of this play being recorded, or nil.
+	run *replay.Ghost
 }
 
 func NewGameScene(game *Game) *GameScene {
+	seed := time.Now().UnixNano()
+	g := newGameScene(game.gameData, game.world.Clone(), seed)
+	if game.recordPath != "" {
+		g.recording = newReplay(game.gameData, game.world, seed)
+	}
+	g.ghost = loadGhost(game.ghost, game.world, game.gameData)
+	g.run = newGhost(game.gameData, game.world)
+	return g
+}
+
+// NewReplayScene returns the game scene that plays r back.
+func NewReplayScene(game *Game, r *replay.Replay) *GameScene {
+	g := newGameScene(game.gameData, game.world.Clone(), r.Seed)
+	g.replay = r
+	return g
+}
+
+func newGameScene(gameData *GameData, world *field.World, seed int64) *GameScene {
+	rand.Seed(seed)
+	c := &input.FrameController{}
 	g := &GameScene{
-		player: NewPlayer(game.gameData),
+		player:     NewPlayer(gameData, world, c),
+		entities:   map[string][]Entity{},
+		controller: c,
 	}
+	g.currentEntities()
 	return g
 }
 
+// clone returns a copy of the scene for a savestate. The copy plays with gameData.
+func (g *GameScene) clone(gameData *GameData) *GameScene {
+	g2 := *g
+	c := *g.controller
+	g2.controller = &c
+	g2.player = g.player.clone(gameData, g2.controller)
+	g2.entities = map[string][]Entity{}
+	for name, es := range g.entities {
+		es2 := make([]Entity, 0, len(es))
+		for _, e := range es {
+			es2 = append(es2, e.Clone())
+		}
+		g2.entities[name] = es2
+	}
+	if g.recording != nil {
+		r := *g.recording
+		r.Frames = append([]byte(nil), g.recording.Frames...)
+		g2.recording = &r
+	}
+	if g.run != nil {
+		r := *g.run
+		r.Frames = append([]replay.GhostFrame(nil), g.run.Frames...)
+		g2.run = &r
+	}
+	return &g2
+}
+
+func newReplay(gameData *GameData, world *field.World, seed int64) *replay.Replay {
+	name, _ := world.Start()
+	return &replay.Replay{
+		Mode:  int(gameModeOf(gameData)),
+		Seed:  seed,
+		Field: filepath.Base(name),
+	}
+}
+
+// currentEntities returns the entities in the field where the player is.
+// The entities are spawned when the player enters the field first, so this must be called only in Update.
+func (g *GameScene) currentEntities() []Entity {
+	name := g.player.fieldName
+	es, ok := g.entities[name]
+	if !ok {
+		es = spawnEntities(g.player.field)
+		g.entities[name] = es
+	}
+	return es
+}
+
 func (g *GameScene) Update(game *Game) {
+	var b input.Buttons
+	if g.replay != nil {
+		// 記録が尽きたらタイトルに戻る
+		if g.frame >= len(g.replay.Frames) {
+			g.gameStateMsg = GAMESTATE_MSG_REQ_TITLE
+			return
+		}
+		b = input.Buttons(g.replay.Frames[g.frame])
+	} else {
+		b = input.ButtonsOf(input.Current())
+	}
+	if g.recording != nil {
+		g.recording.Frames = append(g.recording.Frames, byte(b))
+	}
+	g.controller.SetButtons(b)
+	g.frame++
+
 	g.gameStateMsg = g.player.Update()
+	// ワープした先のエンティティもここで出す (Draw では出さない)
+	es := g.currentEntities()
+	if g.run != nil {
+		recordGhost(g.run, g.player)
+	}
+	if g.player.state == PLAYERSTATE_ITEMGET {
+		return
+	}
+	for _, e := range es {
+		e.Update(g.player.field, g.player)
+		if e.Hitbox().Overlaps(g.player.hitbox()) {
+			g.player.hitEntity()
+		}
+	}
 }
 
 func (g *GameScene) Draw(screen *ebiten.Image, game *Game) {
 	if !game.transparent {
 		if game.gameData.lunkerMode {
-			draw.Draw(screen, "bg", 0, 0, 0, 240, draw.ScreenWidth, draw.ScreenHeight)
+			draw.DrawSprite(screen, "bg_lunker", 0, 0, 0)
 		} else {
-			draw.Draw(screen, "bg", 0, 0, 0, 0, draw.ScreenWidth, draw.ScreenHeight)
+			draw.DrawSprite(screen, "bg", 0, 0, 0)
 		}
 	}
+	g.player.DrawField(screen, game)
+	for _, e := range g.entities[g.player.fieldName] {
+		e.Draw(screen, g.player.view)
+	}
+	if g.ghost != nil {
+		drawGhost(screen, g.ghost, g.frame-1, g.player)
+	}
 	g.player.Draw(screen, game)
 	if input.Current().IsTouchEnabled() {
 		draw.DrawTouchButtons(screen)
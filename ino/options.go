@@ -0,0 +1,137 @@
+package ino
+
+import (
+	"image/color"
+	"log"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/text/language"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/draw"
+	"github.com/hajimehoshi/go-inovation/ino/internal/font"
+	"github.com/hajimehoshi/go-inovation/ino/internal/input"
+)
+
+const (
+	OPTIONS_THEME = iota
+	OPTIONS_LANGUAGE
+	OPTIONS_GHOST
+	OPTIONS_BACK
+	OPTIONS_NUM
+)
+
+const OPTIONS_LINE_HEIGHT = 24
+
+// OptionsScene is the options menu opened from the title.
+//
+// Up and down choose an option, and left, right and the action key change it.
+// An option can also be clicked or touched. Escape or Back returns to the title.
+type OptionsScene struct {
+	gameStateMsg GameStateMsg
+	cursor       int
+}
+
+// nextLanguage returns the language after lang.
+func nextLanguage(lang language.Tag) language.Tag {
+	if lang == language.Japanese {
+		return language.English
+	}
+	return language.Japanese
+}
+
+// optionsTop returns the y position of the first option.
+func optionsTop() int {
+	return (draw.ScreenHeight - OPTIONS_NUM*OPTIONS_LINE_HEIGHT) / 2
+}
+
+func (o *OptionsScene) Update(game *Game) {
+	if input.Current().IsKeyJustPressed(ebiten.KeyUp) {
+		o.cursor = (o.cursor + OPTIONS_NUM - 1) % OPTIONS_NUM
+	}
+	if input.Current().IsKeyJustPressed(ebiten.KeyDown) {
+		o.cursor = (o.cursor + 1) % OPTIONS_NUM
+	}
+
+	switch {
+	case input.Current().IsKeyJustPressed(ebiten.KeyLeft):
+		o.change(game, -1)
+	case input.Current().IsKeyJustPressed(ebiten.KeyRight), input.Current().IsActionKeyJustPressed():
+		o.change(game, 1)
+	}
+
+	if x, y, ok := input.Current().JustPressedPosition(); ok && 0 <= x && x < draw.ScreenWidth && optionsTop() <= y {
+		if i := (y - optionsTop()) / OPTIONS_LINE_HEIGHT; i < OPTIONS_NUM {
+			o.cursor = i
+			o.change(game, 1)
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		o.gameStateMsg = GAMESTATE_MSG_REQ_TITLE
+	}
+}
+
+// change changes the option at the cursor by d steps.
+func (o *OptionsScene) change(game *Game, d int) {
+	switch o.cursor {
+	case OPTIONS_THEME:
+		// 読めないテーマは飛ばす
+		for i := 1; i < len(themes()); i++ {
+			err := draw.SetTheme(nextTheme(d * i))
+			if err == nil {
+				break
+			}
+			log.Print(err)
+		}
+	case OPTIONS_LANGUAGE:
+		game.lang = nextLanguage(game.lang)
+	case OPTIONS_GHOST:
+		game.ghost = nextGhost(game.ghost, d)
+	case OPTIONS_BACK:
+		o.gameStateMsg = GAMESTATE_MSG_REQ_TITLE
+	}
+}
+
+func (o *OptionsScene) label(game *Game, i int) string {
+	switch i {
+	case OPTIONS_THEME:
+		return "Theme: " + filepath.Base(draw.Theme())
+	case OPTIONS_LANGUAGE:
+		if game.lang == language.Japanese {
+			return "Language: Japanese"
+		}
+		return "Language: English"
+	case OPTIONS_GHOST:
+		return ghostLabel(game.ghost)
+	case OPTIONS_BACK:
+		return "Back"
+	}
+	panic("not reached")
+}
+
+func (o *OptionsScene) Draw(screen *ebiten.Image, game *Game) {
+	if !game.transparent {
+		draw.DrawSprite(screen, "bg", 0, 0, 0)
+	}
+
+	const title = "Options"
+	font.DrawText(screen, title, (draw.ScreenWidth-font.Width(title))/2, optionsTop()-OPTIONS_LINE_HEIGHT*3/2, color.Black)
+
+	for i := 0; i < OPTIONS_NUM; i++ {
+		str := o.label(game, i)
+		x := (draw.ScreenWidth - font.Width(str)) / 2
+		y := optionsTop() + i*OPTIONS_LINE_HEIGHT + (OPTIONS_LINE_HEIGHT-font.LineHeight)/2
+		clr := color.RGBA{0x80, 0x80, 0x80, 0xff}
+		if i == o.cursor {
+			clr = color.RGBA{0, 0, 0, 0xff}
+			font.DrawText(screen, ">", x-16, y, clr)
+		}
+		font.DrawText(screen, str, x, y, clr)
+	}
+}
+
+func (o *OptionsScene) Msg() GameStateMsg {
+	return o.gameStateMsg
+}
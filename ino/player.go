@@ -1,7 +1,9 @@
 package ino
 
 import (
-	"math"
+	"encoding/json"
+	"fmt"
+	"image"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/hajimehoshi/go-inovation/ino/internal/field"
 	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
 	"github.com/hajimehoshi/go-inovation/ino/internal/input"
+	"github.com/hajimehoshi/go-inovation/ino/internal/physics"
 )
 
 type PlayerState int
@@ -20,161 +23,163 @@ const (
 	PLAYERSTATE_ITEMGET
 	PLAYERSTATE_MUTEKI
 	PLAYERSTATE_DEAD
+	PLAYERSTATE_CLIMB
 )
 
 const (
-	PLAYER_SPEED         = 2.0
-	PLAYER_GRD_ACCRATIO  = 0.04
-	PLAYER_AIR_ACCRATIO  = 0.01
-	PLAYER_JUMP          = -4.0
-	PLAYER_GRAVITY       = 0.2
-	PLAYER_FALL_SPEEDMAX = 4.0
-	WAIT_TIMER_INTERVAL  = 10
-	LIFE_RATIO           = 400
-	MUTEKI_INTERVAL      = 50
-	START_WAIT_INTERVAL  = 50
-	SCROLLPANEL_SPEED    = 2.0
-
-	LUNKER_JUMP_DAMAGE1 = 40.0
-	LUNKER_JUMP_DAMAGE2 = 96.0
+	WAIT_TIMER_INTERVAL = 10
+	LIFE_RATIO          = physics.LIFE_RATIO
+	MUTEKI_INTERVAL     = physics.MUTEKI_INTERVAL
+	START_WAIT_INTERVAL = 50
+	RESPAWN_WAIT        = 15
 )
 
 type Player struct {
-	life        int
-	jumpCnt     int
-	timer       int
-	position    PositionF
-	speed       PositionF
-	direction   int
-	jumpedPoint PositionF
-	state       PlayerState
-	itemGet     fieldtype.FieldType
-	waitTimer   int
-	gameData    *GameData // TODO(hajimehoshi): Remove this?
-	view        *View
-	field       *field.Field
+	life      int
+	timer     int
+	body      physics.Body
+	state     PlayerState
+	itemGet   fieldtype.FieldType
+	waitTimer int
+	gameData  *GameData // TODO(hajimehoshi): Remove this?
+	view      *View
+	world     *field.World
+	fieldName string
+	field     *field.Field
+	onWarp    bool
+	onSwitch  bool
+
+	controller input.Controller
+
+	// The place where the player respawns after death.
+	checkpointName string
+	checkpoint     PositionF
 }
 
-func NewPlayer(gameData *GameData) *Player {
-	f := field.New(field_data)
+// NewPlayer creates a player driven by controller.
+func NewPlayer(gameData *GameData, world *field.World, controller input.Controller) *Player {
+	name, f := world.Start()
 	x, y := f.GetStartPoint()
-	startPointF := PositionF{float64(x), float64(y)}
+	startPointF := PositionF{X: float64(x), Y: float64(y)}
 	audio.PlayBGM(audio.BGM0)
 	return &Player{
-		gameData:    gameData,
-		field:       f,
-		life:        gameData.lifeMax * LIFE_RATIO,
-		position:    startPointF,
-		jumpedPoint: startPointF,
-		view:        NewView(startPointF),
+		gameData:       gameData,
+		world:          world,
+		fieldName:      name,
+		field:          f,
+		life:           gameData.lifeMax * LIFE_RATIO,
+		body:           physics.NewBody(startPointF),
+		view:           NewView(startPointF),
+		checkpointName: name,
+		checkpoint:     startPointF,
+		controller:     controller,
 	}
 }
 
-func (p *Player) onWall() bool {
-	if p.toFieldOfsY() > field.CHAR_SIZE/4 {
-		return false
-	}
-	if p.field.IsRidable(p.toFieldX(), p.toFieldY()+1) && p.toFieldOfsX() < field.CHAR_SIZE*7/8 {
-		return true
-	}
-	if p.field.IsRidable(p.toFieldX()+1, p.toFieldY()+1) && p.toFieldOfsX() > field.CHAR_SIZE/8 {
-		return true
-	}
-	return false
-}
-
-func (p *Player) isFallable() bool {
-	if !p.onWall() {
-		return false
-	}
-	if p.field.IsWall(p.toFieldX(), p.toFieldY()+1) && p.toFieldOfsX() < field.CHAR_SIZE*7/8 {
-		return false
-	}
-	if p.field.IsWall(p.toFieldX()+1, p.toFieldY()+1) && p.toFieldOfsX() > field.CHAR_SIZE/8 {
-		return false
-	}
-	return true
-}
-
-func (p *Player) isUpperWallBoth() bool {
-	if p.toFieldOfsY() < field.CHAR_SIZE/2 {
-		return false
-	}
-	if p.field.IsWall(p.toFieldX(), p.toFieldY()) && p.field.IsWall(p.toFieldX()+1, p.toFieldY()) {
-		return true
-	}
-	return false
+// clone returns a copy of the player in a copy of the world, for a savestate.
+// The copy is driven by controller and has gameData.
+func (p *Player) clone(gameData *GameData, controller input.Controller) *Player {
+	p2 := *p
+	p2.gameData = gameData
+	p2.controller = controller
+	v := *p.view
+	p2.view = &v
+	p2.world = p.world.Clone()
+	p2.field = p2.world.Field(p.fieldName)
+	return &p2
 }
 
-func (p *Player) isUpperWall() bool {
-	if p.toFieldOfsY() < field.CHAR_SIZE/2 {
-		return false
-	}
-	if p.field.IsWall(p.toFieldX(), p.toFieldY()) && p.toFieldOfsX() < field.CHAR_SIZE*7/8 {
-		return true
-	}
-	if p.field.IsWall(p.toFieldX()+1, p.toFieldY()) && p.toFieldOfsX() > field.CHAR_SIZE/8 {
-		return true
-	}
-	return false
-}
-
-func (p *Player) isLeftWall() bool {
-	if p.field.IsWall(p.toFieldX(), p.toFieldY()) {
-		return true
-	}
-	if p.field.IsWall(p.toFieldX(), p.toFieldY()+1) && p.toFieldOfsY() > field.CHAR_SIZE/8 {
-		return true
-	}
-	return false
-}
-
-func (p *Player) isRightWall() bool {
-	if p.field.IsWall(p.toFieldX()+1, p.toFieldY()) {
-		return true
-	}
-	if p.field.IsWall(p.toFieldX()+1, p.toFieldY()+1) && p.toFieldOfsY() > field.CHAR_SIZE/8 {
-		return true
-	}
-	return false
+type playerJSON struct {
+	Life      int          `json:"life"`
+	Timer     int          `json:"timer"`
+	Body      physics.Body `json:"body"`
+	State     PlayerState  `json:"state"`
+	ItemGet   string       `json:"itemget"`
+	WaitTimer int          `json:"waittimer"`
+	View      *View        `json:"view"`
+	Field     string       `json:"field"`
+	OnWarp    bool         `json:"onwarp"`
+	OnSwitch  bool         `json:"onswitch"`
+
+	CheckpointField string    `json:"checkpointfield"`
+	Checkpoint      PositionF `json:"checkpoint"`
 }
 
-func (p *Player) normalizeToRight() {
-	p.position.X = float64(p.toFieldX() * field.CHAR_SIZE)
-	p.speed.X = 0
+func (p *Player) MarshalJSON() ([]byte, error) {
+	return json.Marshal(playerJSON{
+		Life:            p.life,
+		Timer:           p.timer,
+		Body:            p.body,
+		State:           p.state,
+		ItemGet:         p.itemGet.String(),
+		WaitTimer:       p.waitTimer,
+		View:            p.view,
+		Field:           p.fieldName,
+		OnWarp:          p.onWarp,
+		OnSwitch:        p.onSwitch,
+		CheckpointField: p.checkpointName,
+		Checkpoint:      p.checkpoint,
+	})
 }
 
-func (p *Player) normalizeToLeft() {
-	p.position.X = float64((p.toFieldX() + 1) * field.CHAR_SIZE)
-	p.speed.X = 0
+// UnmarshalJSON restores the states of the player.
+// p must be created by NewPlayer in the world where the states are saved.
+func (p *Player) UnmarshalJSON(data []byte) error {
+	j := playerJSON{
+		View: &View{},
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	f := p.world.Field(j.Field)
+	if f == nil {
+		return fmt.Errorf("ino: no field %s", j.Field)
+	}
+	if p.world.Field(j.CheckpointField) == nil {
+		return fmt.Errorf("ino: no field %s", j.CheckpointField)
+	}
+	itemGet, ok := fieldtype.Parse(j.ItemGet)
+	if !ok {
+		return fmt.Errorf("ino: unknown item %q", j.ItemGet)
+	}
+	p.life = j.Life
+	p.timer = j.Timer
+	p.body = j.Body
+	p.state = j.State
+	p.itemGet = itemGet
+	p.waitTimer = j.WaitTimer
+	p.view = j.View
+	p.fieldName = j.Field
+	p.field = f
+	p.onWarp = j.OnWarp
+	p.onSwitch = j.OnSwitch
+	p.checkpointName = j.CheckpointField
+	p.checkpoint = j.Checkpoint
+	return nil
 }
 
-func (p *Player) normalizeToUpper() {
-	if p.speed.Y < 0 {
-		p.speed.Y = 0
-	}
-	p.position.Y = float64(field.CHAR_SIZE * (p.toFieldY() + 1))
+func (p *Player) onWall() bool {
+	return p.body.OnWall(p.field)
 }
 
 func (p *Player) toFieldX() int {
-	return int(p.position.X) / field.CHAR_SIZE
+	return p.body.ToFieldX()
 }
 
 func (p *Player) toFieldY() int {
-	return int(p.position.Y) / field.CHAR_SIZE
-}
-
-func (p *Player) toFieldOfsX() int {
-	return int(p.position.X) % field.CHAR_SIZE
-}
-
-func (p *Player) toFieldOfsY() int {
-	return int(p.position.Y) % field.CHAR_SIZE
+	return p.body.ToFieldY()
 }
 
 func (p *Player) Update() GameStateMsg {
 	msg := GAMESTATE_MSG_NONE
+	// 自分がいる場所にはブロックを出さない
+	for xx := 0; xx < 2; xx++ {
+		for yy := 0; yy < 2; yy++ {
+			p.field.Occupy(p.toFieldX()+xx, p.toFieldY()+yy)
+		}
+	}
 	p.field.Update()
+	p.body.Carry(p.field)
 	switch p.state {
 	case PLAYERSTATE_START:
 		p.waitTimer++
@@ -182,9 +187,13 @@ func (p *Player) Update() GameStateMsg {
 			p.state = PLAYERSTATE_NORMAL
 		}
 
-	case PLAYERSTATE_NORMAL:
-		p.moveByInput()
-		p.moveNormal()
+	case PLAYERSTATE_NORMAL, PLAYERSTATE_CLIMB:
+		if p.state == PLAYERSTATE_CLIMB || p.grabLadder() {
+			p.moveClimb()
+		} else {
+			p.moveByInput()
+			p.moveNormal()
+		}
 		if p.life < p.gameData.lifeMax*LIFE_RATIO {
 			o_life := p.life
 			p.life++
@@ -212,16 +221,21 @@ func (p *Player) Update() GameStateMsg {
 	case PLAYERSTATE_DEAD:
 		p.moveNormal()
 		audio.PauseBGM()
-		if input.Current().IsActionKeyPressed() && p.waitTimer > 15 {
-			msg = GAMESTATE_MSG_REQ_TITLE
+		if p.controller.IsActionKeyPressed() && p.waitTimer > RESPAWN_WAIT {
+			if p.gameData.permadeath {
+				msg = GAMESTATE_MSG_REQ_TITLE
+				break
+			}
+			p.respawn()
 		}
 	}
 	if p.life < LIFE_RATIO {
 		if p.state != PLAYERSTATE_DEAD {
 			p.waitTimer = 0
+			p.gameData.deaths++
 		}
 		p.state = PLAYERSTATE_DEAD
-		p.direction = 0
+		p.body.Direction = 0
 		p.waitTimer++
 	}
 	return msg
@@ -231,106 +245,164 @@ func (p *Player) moveNormal() {
 	p.timer++
 	p.gameData.Update()
 
-	// 移動＆落下
-	p.speed.Y += PLAYER_GRAVITY
-	p.position.X += p.speed.X
-	p.position.Y += p.speed.Y
-
-	if p.speed.Y > PLAYER_FALL_SPEEDMAX {
-		p.speed.Y = PLAYER_FALL_SPEEDMAX
-	}
+	p.body.Fall(p.field)
 
 	if p.state == PLAYERSTATE_NORMAL {
 		p.checkCollision()
 	}
 
-	// ATARI判定
-	hitLeft := false
-	hitRight := false
-	hitUpper := false
-	if p.onWall() && p.speed.Y >= 0 {
-		if p.gameData.lunkerMode {
-			if p.position.Y-p.jumpedPoint.Y > LUNKER_JUMP_DAMAGE1 {
-				p.state = PLAYERSTATE_MUTEKI
-				p.waitTimer = 0
-				p.life -= LIFE_RATIO
-				audio.PlaySE(audio.SE_DAMAGE)
-			}
-			if p.position.Y-p.jumpedPoint.Y > LUNKER_JUMP_DAMAGE2 {
-				p.state = PLAYERSTATE_MUTEKI
-				p.waitTimer = 0
-				p.life -= LIFE_RATIO * 99
-				audio.PlaySE(audio.SE_DAMAGE)
-			}
+	drop := p.controller.IsActionKeyPressed() && p.controller.IsDirectionKeyPressed(input.DirectionDown)
+	if landed, h := p.body.Collide(p.field, drop); landed && p.gameData.lunkerMode {
+		if d := physics.FallDamage(h); d > 0 {
+			p.state = PLAYERSTATE_MUTEKI
+			p.waitTimer = 0
+			p.life -= d
+			audio.PlaySE(audio.SE_DAMAGE)
 		}
+	}
 
-		if !input.Current().IsActionKeyPressed() || !input.Current().IsDirectionKeyPressed(input.DirectionDown) || !p.isFallable() {
-			if p.speed.Y > 0 {
-				p.speed.Y = 0
-			}
-			p.position.Y = float64(field.CHAR_SIZE * p.toFieldY())
-			p.jumpCnt = 0
-		}
+	// 崩れるブロックに乗った
+	for _, pt := range p.body.Floor(p.field) {
+		p.field.Crumble(pt.X, pt.Y)
+	}
 
-		p.jumpedPoint = p.position
+	p.body.Accelerate(p.field)
+
+	if p.state != PLAYERSTATE_DEAD {
+		p.checkWarp()
+		p.checkCheckpoint()
+		p.checkSwitch()
 	}
-	if p.isLeftWall() && p.speed.X < 0 {
-		hitLeft = true
+
+	p.view.Update(p.body.Position, p.body.Speed)
+}
+
+// grabLadder reports whether the player starts to climb a ladder by the up or down key.
+func (p *Player) grabLadder() bool {
+	in := p.controller
+	// 左右を押している間はつかまらない
+	if in.IsDirectionKeyPressed(input.DirectionLeft) || in.IsDirectionKeyPressed(input.DirectionRight) {
+		return false
 	}
-	if p.isRightWall() && p.speed.X > 0 {
-		hitRight = true
+	if in.IsDirectionKeyPressed(input.DirectionUp) {
+		return p.body.CanClimb(p.field, -physics.PLAYER_CLIMB_SPEED)
 	}
-	if p.isUpperWall() && p.speed.Y <= 0 {
-		hitUpper = true
+	if in.IsDirectionKeyPressed(input.DirectionDown) && !in.IsActionKeyPressed() {
+		return p.body.CanClimb(p.field, physics.PLAYER_CLIMB_SPEED)
 	}
+	return false
+}
 
-	if hitUpper && !hitLeft && !hitRight {
-		p.normalizeToUpper()
+// moveClimb moves the player on the ladder.
+// The up and down keys climb, and the action key with the left or right key jumps off.
+func (p *Player) moveClimb() {
+	p.timer++
+	p.gameData.Update()
+	p.state = PLAYERSTATE_CLIMB
+
+	in := p.controller
+	dir := 0
+	if in.IsDirectionKeyPressed(input.DirectionLeft) {
+		dir = -1
 	}
-	if !hitUpper && hitLeft {
-		p.normalizeToLeft()
+	if in.IsDirectionKeyPressed(input.DirectionRight) {
+		dir = 1
 	}
-	if !hitUpper && hitRight {
-		p.normalizeToRight()
+	var dy float64
+	if in.IsDirectionKeyPressed(input.DirectionUp) {
+		dy = -physics.PLAYER_CLIMB_SPEED
 	}
-	if hitUpper && hitRight {
-		if p.isUpperWallBoth() {
-			p.normalizeToUpper()
-		} else {
-			if p.toFieldOfsX() > p.toFieldOfsY() {
-				p.normalizeToRight()
-			} else {
-				p.normalizeToUpper()
-			}
-		}
+	if in.IsDirectionKeyPressed(input.DirectionDown) {
+		dy = physics.PLAYER_CLIMB_SPEED
 	}
-	if hitUpper && hitLeft {
-		if p.isUpperWallBoth() {
-			p.normalizeToUpper()
-		} else {
-			if field.CHAR_SIZE-p.toFieldOfsX() > p.toFieldOfsY() {
-				p.normalizeToLeft()
-			} else {
-				p.normalizeToUpper()
-			}
-		}
+
+	switch {
+	case in.IsActionKeyJustPressed() && dir != 0:
+		p.body.JumpOff(p.field, dir)
+		p.state = PLAYERSTATE_NORMAL
+		audio.PlaySE(audio.SE_JUMP)
+	case !p.body.Climb(p.field, dy):
+		p.state = PLAYERSTATE_NORMAL
+	}
+
+	p.checkCollision()
+	p.checkWarp()
+	p.checkCheckpoint()
+	p.checkSwitch()
+
+	p.view.Update(p.body.Position, p.body.Speed)
+}
+
+// checkWarp moves the player to the destination when the player enters a warp tile.
+func (p *Player) checkWarp() {
+	x := int(p.body.Position.X+field.CHAR_SIZE/2) / field.CHAR_SIZE
+	y := int(p.body.Position.Y+field.CHAR_SIZE/2) / field.CHAR_SIZE
+	w, ok := p.field.Warp(x, y)
+	if !ok {
+		p.onWarp = false
+		return
+	}
+	// Staying on the warp doesn't warp again. This prevents warping back from the destination.
+	if p.onWarp {
+		return
 	}
 
-	// 床特殊効果
-	switch p.getOnField() {
-	case fieldtype.FIELD_SCROLL_L:
-		p.speed.X = p.speed.X*(1.0-PLAYER_GRD_ACCRATIO) + float64(p.direction*PLAYER_SPEED-SCROLLPANEL_SPEED)*PLAYER_GRD_ACCRATIO
-	case fieldtype.FIELD_SCROLL_R:
-		p.speed.X = p.speed.X*(1.0-PLAYER_GRD_ACCRATIO) + float64(p.direction*PLAYER_SPEED+SCROLLPANEL_SPEED)*PLAYER_GRD_ACCRATIO
-	case fieldtype.FIELD_SLIP:
-		// Do nothing
-	case fieldtype.FIELD_NONE:
-		p.speed.X = p.speed.X*(1.0-PLAYER_AIR_ACCRATIO) + float64(p.direction*PLAYER_SPEED)*PLAYER_AIR_ACCRATIO
-	default:
-		p.speed.X = p.speed.X*(1.0-PLAYER_GRD_ACCRATIO) + float64(p.direction*PLAYER_SPEED)*PLAYER_GRD_ACCRATIO
+	p.fieldName = p.world.Dest(p.fieldName, w)
+	p.field = p.world.Field(p.fieldName)
+	pos := PositionF{X: float64(w.X * field.CHAR_SIZE), Y: float64(w.Y * field.CHAR_SIZE)}
+	dir := p.body.Direction
+	p.body = physics.NewBody(pos)
+	p.body.Direction = dir
+	p.view = NewView(pos)
+	p.onWarp = true
+	audio.PlaySE(audio.SE_HEAL)
+}
+
+// checkCheckpoint records the checkpoint tile the player is on as the respawn position.
+func (p *Player) checkCheckpoint() {
+	x := int(p.body.Position.X+field.CHAR_SIZE/2) / field.CHAR_SIZE
+	y := int(p.body.Position.Y+field.CHAR_SIZE/2) / field.CHAR_SIZE
+	if p.field.GetField(x, y) != fieldtype.FIELD_CHECKPOINT {
+		return
+	}
+	pos := PositionF{X: float64(x * field.CHAR_SIZE), Y: float64(y * field.CHAR_SIZE)}
+	if p.checkpointName == p.fieldName && p.checkpoint == pos {
+		return
 	}
+	p.checkpointName = p.fieldName
+	p.checkpoint = pos
+	audio.PlaySE(audio.SE_ITEMGET2)
+}
+
+// checkSwitch toggles the switch when the player touches it.
+func (p *Player) checkSwitch() {
+	x := int(p.body.Position.X+field.CHAR_SIZE/2) / field.CHAR_SIZE
+	y := int(p.body.Position.Y+field.CHAR_SIZE/2) / field.CHAR_SIZE
+	if p.field.GetField(x, y) != fieldtype.FIELD_SWITCH {
+		p.onSwitch = false
+		return
+	}
+	// Staying on the switch doesn't toggle it again.
+	if p.onSwitch {
+		return
+	}
+	p.field.ToggleSwitch(x, y)
+	p.onSwitch = true
+	audio.PlaySE(audio.SE_JUMP)
+}
 
-	p.view.Update(p.position, p.speed)
+// respawn revives the player at the last checkpoint. The collected items are kept.
+func (p *Player) respawn() {
+	p.fieldName = p.checkpointName
+	p.field = p.world.Field(p.fieldName)
+	p.body = physics.NewBody(p.checkpoint)
+	p.view = NewView(p.checkpoint)
+	p.onWarp = false
+	p.onSwitch = false
+	p.life = p.gameData.lifeMax * LIFE_RATIO
+	p.state = PLAYERSTATE_MUTEKI
+	p.waitTimer = 0
+	audio.ResumeBGM(audio.BGM0)
 }
 
 func (p *Player) moveItemGet() {
@@ -338,37 +410,23 @@ func (p *Player) moveItemGet() {
 		p.waitTimer++
 		return
 	}
-	if input.Current().IsActionKeyJustPressed() {
+	if p.controller.IsActionKeyJustPressed() {
 		p.state = PLAYERSTATE_NORMAL
 		audio.ResumeBGM(audio.BGM0)
 	}
 }
 
 func (p *Player) moveByInput() {
-	if input.Current().IsDirectionKeyPressed(input.DirectionLeft) {
-		p.direction = -1
+	if p.controller.IsDirectionKeyPressed(input.DirectionLeft) {
+		p.body.Direction = -1
 	}
-	if input.Current().IsDirectionKeyPressed(input.DirectionRight) {
-		p.direction = 1
+	if p.controller.IsDirectionKeyPressed(input.DirectionRight) {
+		p.body.Direction = 1
 	}
 
-	if input.Current().IsActionKeyJustPressed() {
-		if ((p.gameData.jumpMax > p.jumpCnt) || p.onWall()) && !input.Current().IsDirectionKeyPressed(input.DirectionDown) {
-			p.speed.Y = PLAYER_JUMP // ジャンプ
-			if !p.onWall() {
-				p.jumpCnt++
-			}
-
-			if math.Abs(p.speed.X) < 0.1 {
-				if p.speed.X < 0 {
-					p.speed.X -= 0.02
-				}
-				if p.speed.X > 0 {
-					p.speed.X += 0.02
-				}
-			}
+	if p.controller.IsActionKeyJustPressed() && !p.controller.IsDirectionKeyPressed(input.DirectionDown) {
+		if p.body.Jump(p.field, p.gameData.jumpMax) {
 			audio.PlaySE(audio.SE_JUMP)
-			p.jumpedPoint = p.position
 		}
 	}
 }
@@ -409,67 +467,75 @@ func (p *Player) checkCollision() {
 			}
 			// トゲ(ダメージ)
 			if p.field.IsSpike(p.toFieldX()+xx, p.toFieldY()+yy) {
-				p.state = PLAYERSTATE_MUTEKI
-				p.waitTimer = 0
-				p.life -= LIFE_RATIO
-				p.speed.Y = PLAYER_JUMP
-				p.jumpCnt = -1 // ダメージ・エキストラジャンプ
-				audio.PlaySE(audio.SE_DAMAGE)
+				p.damage()
 				return
 			}
 		}
 	}
 }
 
-func (p *Player) getOnField() fieldtype.FieldType {
-	if !p.onWall() {
-		return fieldtype.FIELD_NONE
+// damage makes the player lose a life and bounce, and be invincible for a while.
+func (p *Player) damage() {
+	p.state = PLAYERSTATE_MUTEKI
+	p.waitTimer = 0
+	p.life -= LIFE_RATIO
+	p.body.Knockback()
+	audio.PlaySE(audio.SE_DAMAGE)
+}
+
+// hitbox returns the area of the player that enemies hit.
+func (p *Player) hitbox() image.Rectangle {
+	return hitbox(p.body.Position)
+}
+
+// hitEntity damages the player touching an enemy in the same way as spikes.
+func (p *Player) hitEntity() {
+	if p.state != PLAYERSTATE_NORMAL && p.state != PLAYERSTATE_CLIMB {
+		return
+	}
+	p.damage()
+}
+
+// sprite returns the sprite of the player and its frame.
+// The name is empty when the player blinks out.
+func (p *Player) sprite() (string, int) {
+	suffix := ""
+	if p.gameData.lunkerMode {
+		suffix = "_lunker"
+	}
+	if p.state == PLAYERSTATE_DEAD { // 死亡
+		name := "player_dead" + suffix
+		return name, draw.AnimationFrame(name, p.timer)
+	}
+	if p.state == PLAYERSTATE_MUTEKI && p.timer%10 >= 5 {
+		return "", 0
 	}
-	x, y := p.toFieldX(), p.toFieldY()
-	if p.toFieldOfsX() < field.CHAR_SIZE/2 {
-		if p.field.IsRidable(x, y+1) {
-			return p.field.GetField(x, y+1)
+	if p.state == PLAYERSTATE_CLIMB {
+		// はしごでは上り下りで向きを変える
+		name := "player_right" + suffix
+		if int(p.body.Position.Y/8)%2 == 0 {
+			name = "player_left" + suffix
 		}
-		return p.field.GetField(x+1, y+1)
+		return name, 0
 	}
-	if p.field.IsRidable(x+1, y+1) {
-		return p.field.GetField(x+1, y+1)
+	name := "player_right" + suffix
+	if p.body.Direction < 0 {
+		name = "player_left" + suffix
 	}
-	return p.field.GetField(x, y+1)
+	anime := draw.AnimationFrame(name, p.timer)
+	if !p.onWall() {
+		anime = 0
+	}
+	return name, anime
 }
 
 func (p *Player) drawPlayer(screen *ebiten.Image, game *Game) {
-	v := p.view.ToScreenPosition(p.position)
-	vx, vy := int(v.X), int(v.Y)
-	if p.state == PLAYERSTATE_DEAD { // 死亡
-		anime := (p.timer / 6) % 4
-		if game.gameData.lunkerMode {
-			draw.Draw(screen, "ino", vx, vy, field.CHAR_SIZE*(2+anime), 128+field.CHAR_SIZE*2, field.CHAR_SIZE, field.CHAR_SIZE)
-			return
-		}
-		draw.Draw(screen, "ino", vx, vy, field.CHAR_SIZE*(2+anime), 128, field.CHAR_SIZE, field.CHAR_SIZE)
-		return
-	}
-	if p.state != PLAYERSTATE_MUTEKI || p.timer%10 < 5 {
-		anime := (p.timer / 6) % 2
-		if !p.onWall() {
-			anime = 0
-		}
-		if p.direction < 0 {
-			if game.gameData.lunkerMode {
-				draw.Draw(screen, "ino", vx, vy, field.CHAR_SIZE*anime, 128+field.CHAR_SIZE*2, field.CHAR_SIZE, field.CHAR_SIZE)
-				return
-			}
-			draw.Draw(screen, "ino", vx, vy, field.CHAR_SIZE*anime, 128, field.CHAR_SIZE, field.CHAR_SIZE)
-			return
-		}
-		if game.gameData.lunkerMode {
-			draw.Draw(screen, "ino", vx, vy, field.CHAR_SIZE*anime, 128+field.CHAR_SIZE*3, field.CHAR_SIZE, field.CHAR_SIZE)
-			return
-		}
-		draw.Draw(screen, "ino", vx, vy, field.CHAR_SIZE*anime, 128+field.CHAR_SIZE, field.CHAR_SIZE, field.CHAR_SIZE)
+	name, anime := p.sprite()
+	if name == "" {
 		return
 	}
+	v := p.view.ToScreenPosition(p.body.Position)
+	draw.DrawSprite(screen, name, anime, int(v.X), int(v.Y))
 }
 
 func (p *Player) drawLife(screen *ebiten.Image, game *Game) {
@@ -478,38 +544,31 @@ func (p *Player) drawLife(screen *ebiten.Image, game *Game) {
 			continue
 		}
 		if p.life >= (t+1)*LIFE_RATIO {
-			draw.Draw(screen, "ino",
-				field.CHAR_SIZE*t, 0, field.CHAR_SIZE*3, 128+field.CHAR_SIZE*1, field.CHAR_SIZE, field.CHAR_SIZE)
+			draw.DrawSprite(screen, "life", 0, field.CHAR_SIZE*t, 0)
 			continue
 		}
-		draw.Draw(screen, "ino",
-			field.CHAR_SIZE*t, 0, field.CHAR_SIZE*4, 128+field.CHAR_SIZE*1, field.CHAR_SIZE, field.CHAR_SIZE)
+		draw.DrawSprite(screen, "life_empty", 0, field.CHAR_SIZE*t, 0)
 	}
 }
 
 func (p *Player) drawItems(screen *ebiten.Image, game *Game) {
 	for t := fieldtype.FIELD_ITEM_FUJI; t < fieldtype.FIELD_ITEM_MAX; t++ {
+		x := draw.ScreenWidth - field.CHAR_SIZE/4*(int(fieldtype.FIELD_ITEM_MAX)-2-int(t))
 		if !game.gameData.itemGetFlags[t] {
-			draw.Draw(screen, "ino",
-				draw.ScreenWidth-field.CHAR_SIZE/4*(int(fieldtype.FIELD_ITEM_MAX)-2-int(t)), 0, // 無
-				field.CHAR_SIZE*5, 128+field.CHAR_SIZE, field.CHAR_SIZE/4, field.CHAR_SIZE/2)
+			draw.DrawSprite(screen, "item_slot_empty", 0, x, 0) // 無
 			continue
 		}
 		// クリア条件アイテムは専用グラフィック
 		if IsItemForClear(t) {
-			for i, c := range clearFlagItems {
+			for i, c := range fieldtype.ClearFlagItems {
 				if c != t {
 					continue
 				}
-				draw.Draw(screen, "ino",
-					draw.ScreenWidth-field.CHAR_SIZE/4*(int(fieldtype.FIELD_ITEM_MAX)-2-int(t)), 0,
-					field.CHAR_SIZE*5+field.CHAR_SIZE/4*(i+2), 128+field.CHAR_SIZE, field.CHAR_SIZE/4, field.CHAR_SIZE/2)
+				draw.DrawSprite(screen, "item_slot_clear", i, x, 0)
 			}
 			continue
 		}
-		draw.Draw(screen, "ino",
-			draw.ScreenWidth-field.CHAR_SIZE/4*(int(fieldtype.FIELD_ITEM_MAX)-2-int(t)), 0, // 有
-			field.CHAR_SIZE*5+field.CHAR_SIZE/4, 128+field.CHAR_SIZE, field.CHAR_SIZE/4, field.CHAR_SIZE/2)
+		draw.DrawSprite(screen, "item_slot", 0, x, 0) // 有
 	}
 }
 
@@ -519,22 +578,27 @@ func (p *Player) drawMessage(screen *ebiten.Image, game *Game) {
 		t := WAIT_TIMER_INTERVAL - p.waitTimer
 		draw.DrawItemMessage(screen, p.itemGet, (draw.ScreenHeight-96)/2+24-t*t, game.lang)
 		draw.DrawItemFrame(screen, (draw.ScreenWidth-32)/2, (draw.ScreenHeight-96)/2-t*t-24)
-		it := int(p.itemGet) - (int(fieldtype.FIELD_ITEM_BORDER) + 1)
+		sx, sy := field.TypeImagePosition(p.itemGet, 0)
 		draw.Draw(screen, "ino", (draw.ScreenWidth-16)/2, (draw.ScreenHeight-96)/2-int(t)*int(t)-16,
-			(it%16)*field.CHAR_SIZE, (it/16+4)*field.CHAR_SIZE, field.CHAR_SIZE, field.CHAR_SIZE)
+			sx, sy, field.CHAR_SIZE, field.CHAR_SIZE)
 	case PLAYERSTATE_START:
-		key := "msg_" + game.lang.String()
-		draw.Draw(screen, key, (draw.ScreenWidth-256)/2, 64+(draw.ScreenHeight-240)/2, 0, 96, 256, 32)
+		draw.DrawSprite(screen, "message_start_"+game.lang.String(), 0, (draw.ScreenWidth-256)/2, 64+(draw.ScreenHeight-240)/2)
 	case PLAYERSTATE_DEAD:
-		key := "msg_" + game.lang.String()
-		draw.Draw(screen, key, (draw.ScreenWidth-256)/2, 64+(draw.ScreenHeight-240)/2, 0, 128, 256, 32)
+		draw.DrawSprite(screen, "message_dead_"+game.lang.String(), 0, (draw.ScreenWidth-256)/2, 64+(draw.ScreenHeight-240)/2)
 	}
 }
 
-func (p *Player) Draw(screen *ebiten.Image, game *Game) {
+// DrawField draws the field around the player.
+func (p *Player) DrawField(screen *ebiten.Image, game *Game) {
 	po := p.view.GetPosition()
-	p.field.Draw(screen, game.gameData, int(po.X), int(po.Y))
+	draw.DrawField(screen, p.field, game.gameData, int(po.X), int(po.Y))
+}
+
+// Draw draws the player, the zones and the status over the field.
+func (p *Player) Draw(screen *ebiten.Image, game *Game) {
 	p.drawPlayer(screen, game)
+	po := p.view.GetPosition()
+	draw.DrawZones(screen, p.field, game.gameData, int(po.X), int(po.Y))
 	p.drawLife(screen, game)
 	p.drawItems(screen, game)
 	p.drawMessage(screen, game)
@@ -0,0 +1,223 @@
+package ino
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/assets"
+	"github.com/hajimehoshi/go-inovation/ino/internal/fieldtype"
+	"github.com/hajimehoshi/go-inovation/ino/internal/replay"
+)
+
+const (
+	SESSION_VERSION       = 1
+	SESSION_SAVE_INTERVAL = 60 * 60 // 1分ごとに保存する
+)
+
+// sessionJSON is a play in progress, saved so that it can be continued after quitting or crashing.
+type sessionJSON struct {
+	Version int `json:"version"`
+
+	// Field is the name of the field where the game starts.
+	Field string `json:"field"`
+
+	GameData *GameData       `json:"gamedata"`
+	Player   json.RawMessage `json:"player"`
+
+	// World is the fields with their states by their names.
+	World map[string]json.RawMessage `json:"world"`
+
+	// Entities are the entities in each field.
+	Entities map[string][]entityJSON `json:"entities"`
+
+	Frame int `json:"frame"`
+
+	// Run is the ghost of the play so far in the ghost file format, so that the play continued can be
+	// the personal best. It is missing when the play isn't recorded as a ghost.
+	Run []byte `json:"run,omitempty"`
+}
+
+type entityJSON struct {
+	Type  string          `json:"type"`
+	State json.RawMessage `json:"state"`
+}
+
+// sessionPath returns the file of the session.
+func sessionPath() (string, error) {
+	dir, err := assets.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "session.json"), nil
+}
+
+// sessionPath returns the file of the session, or false when the session can't be saved.
+// The session is disabled at the first error so that the same error is not logged again and again.
+func (g *Game) sessionPath() (string, bool) {
+	if g.sessionDisabled {
+		return "", false
+	}
+	path, err := sessionPath()
+	if err != nil {
+		log.Printf("ino: the session is disabled: %v", err)
+		g.sessionDisabled = true
+		return "", false
+	}
+	return path, true
+}
+
+// hasSession reports whether there is a session to continue.
+func hasSession() bool {
+	path, err := sessionPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// saveSession writes the play of s to the session file at path.
+func saveSession(path string, s *GameScene) error {
+	name, _ := s.player.world.Start()
+	j := sessionJSON{
+		Version:  SESSION_VERSION,
+		Field:    filepath.Base(name),
+		GameData: s.player.gameData,
+		World:    map[string]json.RawMessage{},
+		Entities: map[string][]entityJSON{},
+		Frame:    s.frame,
+	}
+	var err error
+	if j.Player, err = json.Marshal(s.player); err != nil {
+		return err
+	}
+	// 長すぎるゴーストは自己ベストにならないので保存しない
+	if s.run != nil && len(s.run.Frames) <= replay.MaxFrames {
+		var buf bytes.Buffer
+		if err := s.run.Write(&buf); err != nil {
+			return err
+		}
+		j.Run = buf.Bytes()
+	}
+	for _, name := range s.player.world.Names() {
+		data, err := json.Marshal(s.player.world.Field(name))
+		if err != nil {
+			return err
+		}
+		j.World[name] = data
+	}
+	for name, es := range s.entities {
+		ejs := []entityJSON{}
+		for _, e := range es {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			ejs = append(ejs, entityJSON{
+				Type:  e.Type().String(),
+				State: data,
+			})
+		}
+		j.Entities[name] = ejs
+	}
+
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// 書き込み中に落ちても前のセッションが残るようにする
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeSession removes the session file at path after the play ends.
+func removeSession(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// loadSession returns the game scene that continues the play in the session file.
+// The play continued is not recorded by -record, since a replay can't start in the middle of a play.
+func loadSession(game *Game) (*GameScene, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var j sessionJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("ino: %s: %w", path, err)
+	}
+	if j.Version < 1 || j.Version > SESSION_VERSION {
+		return nil, fmt.Errorf("ino: %s: unsupported version %d", path, j.Version)
+	}
+	name, _ := game.world.Start()
+	if j.Field != filepath.Base(name) {
+		return nil, fmt.Errorf("ino: %s is played on %s but the field is %s", path, j.Field, filepath.Base(name))
+	}
+	if j.GameData == nil {
+		return nil, fmt.Errorf("ino: %s: no game data", path)
+	}
+
+	g := newGameScene(j.GameData, game.world.Clone(), time.Now().UnixNano())
+	for name, data := range j.World {
+		f := g.player.world.Field(name)
+		if f == nil {
+			return nil, fmt.Errorf("ino: %s: no field %s", path, name)
+		}
+		if err := json.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("ino: %s: %s: %w", path, name, err)
+		}
+	}
+	if err := json.Unmarshal(j.Player, g.player); err != nil {
+		return nil, fmt.Errorf("ino: %s: %w", path, err)
+	}
+	g.entities = map[string][]Entity{}
+	for name, ejs := range j.Entities {
+		es := []Entity{}
+		for _, ej := range ejs {
+			t, _ := fieldtype.Parse(ej.Type)
+			spawn, ok := entitySpawners[t]
+			if !ok {
+				return nil, fmt.Errorf("ino: %s: unknown entity %q", path, ej.Type)
+			}
+			e := spawn(PositionF{})
+			if err := json.Unmarshal(ej.State, e); err != nil {
+				return nil, fmt.Errorf("ino: %s: %w", path, err)
+			}
+			es = append(es, e)
+		}
+		g.entities[name] = es
+	}
+	g.currentEntities()
+	g.frame = j.Frame
+	g.ghost = loadGhost(game.ghost, game.world, j.GameData)
+	if j.Run != nil {
+		run, err := replay.ReadGhost(bytes.NewReader(j.Run))
+		if err != nil {
+			return nil, fmt.Errorf("ino: %s: %w", path, err)
+		}
+		g.run = run
+	}
+
+	game.gameData = j.GameData
+	return g, nil
+}
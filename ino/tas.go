@@ -0,0 +1,138 @@
+package ino
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/hajimehoshi/go-inovation/ino/internal/audio"
+)
+
+const (
+	TAS_SLOT_NUM     = 10
+	TAS_MESSAGE_TIME = 120
+)
+
+// savestate is a snapshot of a play.
+type savestate struct {
+	scene    *GameScene
+	gameData *GameData
+}
+
+// TAS is the tool-assisted mode to research routes.
+//
+// Tab pauses and resumes the game, and the period key advances a paused game by a frame.
+// The minus key changes the speed to 1/2, 1/4 and back to 1.
+// Shift and a digit key save the play to the slot, and a digit key loads it.
+type TAS struct {
+	paused bool
+	speed  int // 1, 2 or 4 ticks for a frame
+	tick   int
+
+	slots [TAS_SLOT_NUM]*savestate
+
+	message      string
+	messageTimer int
+}
+
+func newTAS() *TAS {
+	return &TAS{
+		speed: 1,
+	}
+}
+
+// Update handles the keys of the TAS mode, and reports whether the game advances in this tick.
+func (t *TAS) Update(game *Game) bool {
+	if t.messageTimer > 0 {
+		t.messageTimer--
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		t.paused = !t.paused
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyMinus) {
+		t.speed *= 2
+		if t.speed > 4 {
+			t.speed = 1
+		}
+		t.tick = 0
+	}
+
+	for i := 0; i < TAS_SLOT_NUM; i++ {
+		if !inpututil.IsKeyJustPressed(ebiten.KeyDigit0 + ebiten.Key(i)) {
+			continue
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			t.save(game, i)
+		} else {
+			t.load(game, i)
+		}
+		return false
+	}
+
+	if t.paused {
+		return inpututil.IsKeyJustPressed(ebiten.KeyPeriod)
+	}
+	t.tick++
+	if t.tick < t.speed {
+		return false
+	}
+	t.tick = 0
+	return true
+}
+
+func (t *TAS) save(game *Game, slot int) {
+	s, ok := game.scene.(*GameScene)
+	if !ok {
+		t.showMessage("Not in a game")
+		return
+	}
+	gd := game.gameData.clone()
+	t.slots[slot] = &savestate{
+		scene:    s.clone(gd),
+		gameData: gd,
+	}
+	t.showMessage(fmt.Sprintf("Saved to slot %d", slot))
+}
+
+func (t *TAS) load(game *Game, slot int) {
+	st := t.slots[slot]
+	if st == nil {
+		t.showMessage(fmt.Sprintf("Slot %d is empty", slot))
+		return
+	}
+	if _, ok := game.scene.(*GameScene); !ok {
+		if err := audio.PlayBGM(audio.BGM0); err != nil {
+			t.showMessage(err.Error())
+			return
+		}
+	}
+	// スロットを何度でも読めるように複製する
+	game.gameData = st.gameData.clone()
+	game.scene = st.scene.clone(game.gameData)
+	t.showMessage(fmt.Sprintf("Loaded slot %d", slot))
+}
+
+func (t *TAS) showMessage(msg string) {
+	t.message = msg
+	t.messageTimer = TAS_MESSAGE_TIME
+}
+
+// Status returns the text shown on the screen in the TAS mode.
+func (t *TAS) Status(game *Game) string {
+	s := "TAS:"
+	if g, ok := game.scene.(*GameScene); ok {
+		s += fmt.Sprintf(" frame %d", g.frame)
+	}
+	if t.speed > 1 {
+		s += fmt.Sprintf(" 1/%d", t.speed)
+	}
+	if t.paused {
+		s += " paused"
+	}
+	if t.messageTimer > 0 {
+		s += "\n" + t.message
+	}
+	return s
+}
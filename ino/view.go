@@ -1,14 +1,14 @@
 package ino
 
 import (
+	"encoding/json"
+
 	"github.com/hajimehoshi/go-inovation/ino/internal/draw"
 	"github.com/hajimehoshi/go-inovation/ino/internal/input"
+	"github.com/hajimehoshi/go-inovation/ino/internal/physics"
 )
 
-type PositionF struct {
-	X float64
-	Y float64
-}
+type PositionF = physics.PositionF
 
 type View struct {
 	position PositionF
@@ -23,7 +23,7 @@ func NewView(position PositionF) *View {
 func (v *View) ToScreenPosition(p PositionF) PositionF {
 	x := p.X - v.GetPosition().X + draw.ScreenWidth/2
 	y := p.Y - v.GetPosition().Y + draw.ScreenHeight/2
-	return PositionF{x, y}
+	return PositionF{X: x, Y: y}
 }
 
 func (v *View) GetPosition() PositionF {
@@ -39,3 +39,11 @@ func (v *View) Update(position, speed PositionF) {
 	v.position.X = v.position.X*0.95 + (position.X+speed.X*VIEW_DIRECTION_OFFSET)*0.05
 	v.position.Y = v.position.Y*0.95 + position.Y*0.05
 }
+
+func (v *View) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.position)
+}
+
+func (v *View) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &v.position)
+}
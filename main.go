@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -12,11 +13,30 @@ import (
 )
 
 var (
+	fieldFile   = flag.String("field", "", "level file to play instead of the built-in field")
+	edit        = flag.Bool("edit", false, "open the level file specified by -field in the editor")
 	memProfile  = flag.String("memprofile", "", "write memory profile to file")
 	traceOut    = flag.String("trace", "", "write trace to file")
 	transparent = flag.Bool("transparent", false, "background transparency")
+	record      = flag.String("record", "", "record the plays into the replay file")
+	replayFile  = flag.String("replay", "", "play the replay file back")
+	tas         = flag.Bool("tas", false, "enable the TAS mode with pause, frame advance, slow motion and savestates")
 )
 
+// exitWithFieldError prints err of the level file to stderr and exits.
+// All the errors in the file are printed one per line, like inofieldlint.
+func exitWithFieldError(err error) {
+	var errs interface{ Unwrap() []error }
+	if errors.As(err, &errs) {
+		for _, e := range errs.Unwrap() {
+			fmt.Fprintln(os.Stderr, e)
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(1)
+}
+
 func main() {
 	flag.Parse()
 
@@ -36,6 +56,37 @@ func main() {
 		panic(err)
 	}
 
+	if *edit {
+		if *fieldFile == "" {
+			fmt.Fprintln(os.Stderr, "-edit requires -field")
+			os.Exit(2)
+		}
+		if err := game.StartEditor(*fieldFile); err != nil {
+			exitWithFieldError(err)
+		}
+	} else if *fieldFile != "" {
+		if err := game.LoadField(*fieldFile); err != nil {
+			exitWithFieldError(err)
+		}
+	}
+
+	if *record != "" {
+		game.RecordTo(*record)
+	}
+	if *replayFile != "" {
+		if *edit {
+			fmt.Fprintln(os.Stderr, "-replay cannot be used with -edit")
+			os.Exit(2)
+		}
+		if err := game.StartReplay(*replayFile); err != nil {
+			panic(err)
+		}
+	}
+
+	if *tas {
+		game.EnableTAS()
+	}
+
 	if *transparent {
 		ebiten.SetScreenTransparent(true)
 		ebiten.SetWindowDecorated(false)
@@ -48,6 +99,9 @@ func main() {
 	if err := ebiten.RunGame(game); err != nil {
 		panic(err)
 	}
+	if err := game.Close(); err != nil {
+		panic(err)
+	}
 	if *memProfile != "" {
 		f, err := os.Create(*memProfile)
 		if err != nil {